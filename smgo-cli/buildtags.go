@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"go/build"
+	"go/build/constraint"
+	"strings"
+)
+
+// buildFlags are the flags selecting the build context of the files in the directories
+// matched by patterns, so platform-specific files are included or excluded deliberately.
+type buildFlags struct {
+	goos   *string
+	goarch *string
+	tags   *string
+}
+
+func addBuildFlags(fs *flag.FlagSet) *buildFlags {
+	return &buildFlags{
+		goos:   fs.String("goos", "", "include only the files built for GOOS"),
+		goarch: fs.String("goarch", "", "include only the files built for GOARCH"),
+		tags:   fs.String("tags", "", "include only the files built with the comma-separated build tags"),
+	}
+}
+
+// context returns the build context selected by the flags, or nil if none is given. Unset
+// GOOS and GOARCH are the ones of the host.
+func (f *buildFlags) context() *build.Context {
+	if *f.goos == "" && *f.goarch == "" && *f.tags == "" {
+		return nil
+	}
+	ctx := build.Default
+	if *f.goos != "" {
+		ctx.GOOS = *f.goos
+	}
+	if *f.goarch != "" {
+		ctx.GOARCH = *f.goarch
+	}
+	if *f.tags != "" {
+		ctx.BuildTags = strings.Split(*f.tags, ",")
+	}
+	return &ctx
+}
+
+// ManifestBuild is the build context selecting the files of a manifest.
+type ManifestBuild struct {
+	GOOS   string   `json:"goos"`
+	GOARCH string   `json:"goarch"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+func manifestBuild(ctx *build.Context) *ManifestBuild {
+	if ctx == nil {
+		return nil
+	}
+	return &ManifestBuild{
+		GOOS:   ctx.GOOS,
+		GOARCH: ctx.GOARCH,
+		Tags:   ctx.BuildTags,
+	}
+}
+
+// buildConstraint returns the build constraint of the GO source code src (the expression of
+// its //go:build line, or of its // +build lines), or "" if there's none. The constraints
+// implied by the file name (e.g. _linux.go) aren't included.
+func buildConstraint(src []byte) string {
+	var plusBuild constraint.Expr
+	s := bufio.NewScanner(bytes.NewReader(src))
+	inComment := false
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if inComment {
+			inComment = !strings.Contains(line, "*/")
+			continue
+		}
+		switch {
+		case constraint.IsGoBuild(line):
+			if expr, err := constraint.Parse(line); err == nil {
+				return expr.String()
+			}
+		case constraint.IsPlusBuild(line):
+			expr, err := constraint.Parse(line)
+			if err != nil {
+				continue
+			}
+			if plusBuild == nil {
+				plusBuild = expr
+			} else {
+				plusBuild = &constraint.AndExpr{X: plusBuild, Y: expr}
+			}
+		case line == "" || strings.HasPrefix(line, "//"):
+		case strings.HasPrefix(line, "/*"):
+			inComment = !strings.Contains(line, "*/")
+		default:
+			// the package clause ends the header of the file
+			if plusBuild == nil {
+				return ""
+			}
+			return plusBuild.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+)
+
+// Index maps the qualified names of the declarations of a repository (e.g. "Person.Name")
+// to their locations. Files keeps the declarations of every file with the hash of its
+// content, so unchanged files aren't parsed again when the index is updated.
+type Index struct {
+	Version int                         `json:"version"`
+	Files   map[string]*IndexFile       `json:"files"`
+	Symbols map[string][]*IndexLocation `json:"symbols"`
+}
+
+// indexVersion is the version of the symbols of the index: the files of an index of another
+// version are parsed again. Version 1 qualifies the names of the methods with their
+// receivers.
+const indexVersion = 1
+
+type IndexFile struct {
+	Hash    string         `json:"hash"`
+	Symbols []*IndexSymbol `json:"symbols"`
+}
+
+// IndexSymbol is a declaration of a file. Span is the inclusive range of its offsets and
+// Hash the hash of its source code, as in the manifest.
+type IndexSymbol struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Span []int  `json:"span"`
+	Hash string `json:"hash"`
+}
+
+type IndexLocation struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Span []int  `json:"span"`
+	Hash string `json:"hash"`
+}
+
+// index runs "smgo-cli index [-o index.json] <pattern>...", writing the index of the UTF-8 GO
+// files matched by the patterns (see manifest). If the output file exists, it's updated:
+// only the new and changed files are parsed. Progress is reported to w.
+func index(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("index", flag.ContinueOnError)
+	output := fs.String("o", "index.json", "index file")
+	paths, _, err := parsePatterns(fs, args)
+	if err != nil {
+		return err
+	}
+	previous, err := readIndex(*output)
+	if err != nil {
+		return err
+	}
+
+	if previous.Version != indexVersion {
+		previous.Files = nil
+	}
+
+	idx := &Index{
+		Version: indexVersion,
+		Files:   make(map[string]*IndexFile, len(paths)),
+		Symbols: make(map[string][]*IndexLocation),
+	}
+	parsed := 0
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file, ok := previous.Files[path]
+		if !ok || file.Hash != hash(src) {
+			file, err = indexFile(src, path)
+			if err != nil {
+				return err
+			}
+			parsed++
+		}
+		idx.Files[path] = file
+		for _, symbol := range file.Symbols {
+			idx.Symbols[symbol.Name] = append(idx.Symbols[symbol.Name], &IndexLocation{
+				Path: path,
+				Type: symbol.Type,
+				Span: symbol.Span,
+				Hash: symbol.Hash,
+			})
+		}
+	}
+	err = writeJSON(idx, *output, w)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%d files indexed, %d parsed\n", len(paths), parsed)
+	return nil
+}
+
+// readIndex reads the index in path, returning an empty index if it doesn't exist.
+func readIndex(path string) (*Index, error) {
+	idx := &Index{}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading index")
+	}
+	err = json.Unmarshal(content, idx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid index %s", path)
+	}
+	return idx, nil
+}
+
+func indexFile(src []byte, path string) (*IndexFile, error) {
+	opts, err := parseOptions(path)
+	if err != nil {
+		return nil, err
+	}
+	dtFile, err := smgo.Parse(bytes.NewReader(src), "UTF-8", opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", path)
+	}
+	file := &IndexFile{
+		Hash:    hash(src),
+		Symbols: []*IndexSymbol{},
+	}
+	addIndexSymbols(file, dtFile.Children, "", src)
+	return file, nil
+}
+
+// addIndexSymbols adds the declarations in nodes to file, qualifying their names with
+// qualifier (and the names of methods with their receivers, e.g. "Person.SayHi"). Comments
+// and the package clause aren't indexed.
+func addIndexSymbols(file *IndexFile, nodes []smgo.Node, qualifier string, src []byte) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *smgo.Terminal:
+			if n.Type == smgo.Comment || n.Type == smgo.PackageNode {
+				continue
+			}
+			name := qualifier + n.Name
+			if n.Receiver != "" {
+				name = qualifier + n.Receiver + "." + n.Name
+			}
+			file.Symbols = append(file.Symbols, &IndexSymbol{
+				Name: name,
+				Type: toType(n),
+				Span: []int{n.Span.Start, n.Span.End},
+				Hash: hash(spanText(src, n.Span.Start, n.Span.End)),
+			})
+		case *smgo.Container:
+			file.Symbols = append(file.Symbols, &IndexSymbol{
+				Name: qualifier + n.Name,
+				Type: toType(n),
+				Span: []int{n.HeaderSpan.Start, n.FooterSpan.End},
+				Hash: hash(spanText(src, n.HeaderSpan.Start, n.FooterSpan.End)),
+			})
+			childQualifier := qualifier
+			if n.Type == smgo.StructNode || n.Type == smgo.InterfaceNode {
+				childQualifier = qualifier + n.Name + "."
+			}
+			for _, child := range n.Children {
+				// the methods grouped under their types (see smgo.WithMethodGrouping) are
+				// qualified with their receivers only
+				if t, ok := child.(*smgo.Terminal); ok && t.Receiver != "" {
+					addIndexSymbols(file, []smgo.Node{t}, qualifier, src)
+					continue
+				}
+				addIndexSymbols(file, []smgo.Node{child}, childQualifier, src)
+			}
+		}
+	}
+}
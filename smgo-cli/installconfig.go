@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const externalParsersConf = "externalparsers.conf"
+
+// installConfig registers the smgo-cli executable as the external parser of .go files in the
+// externalparsers.conf file of every directory in dirs, or of the configuration directories
+// of SemanticMerge, Plastic SCM and gmaster if dirs is empty. Progress is reported to w.
+func installConfig(w io.Writer, dirs []string) error {
+	cli, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "error locating smgo-cli")
+	}
+	cli, err = filepath.EvalSymlinks(cli)
+	if err != nil {
+		return errors.Wrap(err, "error locating smgo-cli")
+	}
+	err = checkExecutable(cli)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "parser: %s\n", cli)
+
+	if len(dirs) == 0 {
+		dirs, err = configDirs()
+		if err != nil {
+			return err
+		}
+	}
+	for _, dir := range dirs {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return errors.Wrapf(err, "error creating %s", dir)
+		}
+		conf := filepath.Join(dir, externalParsersConf)
+		err = updateExternalParsers(conf, cli)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s: OK\n", conf)
+	}
+	return nil
+}
+
+// checkExecutable verifies that path is an executable file.
+func checkExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrap(err, "error checking smgo-cli")
+	}
+	if !info.Mode().IsRegular() {
+		return errors.Errorf("%s isn't a regular file", path)
+	}
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		return errors.Errorf("%s isn't executable", path)
+	}
+	return nil
+}
+
+// configDirs returns the configuration directories of the tools supporting external parsers
+// on the current OS: SemanticMerge and Plastic SCM share theirs; gmaster is Windows only.
+func configDirs() ([]string, error) {
+	if runtime.GOOS == "windows" {
+		localAppData := os.Getenv("LOCALAPPDATA")
+		if localAppData == "" {
+			return nil, errors.New("LOCALAPPDATA isn't set")
+		}
+		return []string{
+			filepath.Join(localAppData, "plastic4"),
+			filepath.Join(localAppData, "gmaster", "config"),
+		}, nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return nil, errors.New("HOME isn't set")
+	}
+	return []string{filepath.Join(home, ".plastic4")}, nil
+}
+
+// updateExternalParsers sets cli as the parser of .go files in the externalparsers.conf file
+// conf, keeping the parsers of other extensions.
+func updateExternalParsers(conf, cli string) error {
+	content, err := ioutil.ReadFile(conf)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error reading %s", conf)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(strings.ToLower(line), ".go=") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, ".go="+cli)
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteString(newLine)
+	}
+	err = ioutil.WriteFile(conf, buf.Bytes(), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "error writing %s", conf)
+	}
+	return nil
+}
+
+// newLine is the line terminator of the configuration files on the current OS.
+var newLine = func() string {
+	if runtime.GOOS == "windows" {
+		return "\r\n"
+	}
+	return "\n"
+}()
@@ -0,0 +1,169 @@
+package main
+
+import "github.com/jriquelme/SemanticMergeGO/smgo"
+
+type File struct {
+	Type                  string          `json:"type"`
+	Name                  string          `json:"name"`
+	LocationSpan          LocationSpan    `json:"locationSpan"`
+	FooterSpan            []int           `json:"footerSpan"`
+	ParsingErrorsDetected bool            `json:"parsingErrorsDetected"`
+	Children              []interface{}   `json:"children,omitempty"`
+	ParsingErrors         []*ParsingError `json:"parsingErrors,omitempty"`
+	Warnings              []*Warning      `json:"warnings,omitempty"`
+	LineEndings           string          `json:"lineEndings"`
+	FirstMixedLine        int             `json:"firstMixedLine,omitempty"`
+}
+
+type Container struct {
+	Type         string        `json:"type"`
+	Name         string        `json:"name"`
+	ID           string        `json:"id,omitempty"`
+	Exported     bool          `json:"exported,omitempty"`
+	Deprecated   bool          `json:"deprecated,omitempty"`
+	LocationSpan LocationSpan  `json:"locationSpan"`
+	HeaderSpan   []int         `json:"headerSpan"`
+	FooterSpan   []int         `json:"footerSpan"`
+	Children     []interface{} `json:"children,omitempty"`
+	Metrics      *Metrics      `json:"metrics,omitempty"`
+}
+
+type Terminal struct {
+	Type         string       `json:"type"`
+	Name         string       `json:"name"`
+	ID           string       `json:"id,omitempty"`
+	Exported     bool         `json:"exported,omitempty"`
+	Deprecated   bool         `json:"deprecated,omitempty"`
+	LocationSpan LocationSpan `json:"locationSpan"`
+	Span         []int        `json:"span"`
+	Receiver     string       `json:"receiver,omitempty"`
+	Alias        bool         `json:"alias,omitempty"`
+	Complexity   int          `json:"complexity,omitempty"`
+	Metrics      *Metrics     `json:"metrics,omitempty"`
+}
+
+// LocationSpan is a struct, not a map, so the start is written before the end.
+type LocationSpan struct {
+	Start []int `json:"start"`
+	End   []int `json:"end"`
+}
+
+type Metrics struct {
+	Lines        int `json:"lines"`
+	CommentLines int `json:"commentLines"`
+	Bytes        int `json:"bytes"`
+}
+
+type ParsingError struct {
+	Location []int  `json:"location"`
+	Message  string `json:"message"`
+}
+
+type Warning struct {
+	Location []int  `json:"location"`
+	Message  string `json:"message"`
+}
+
+func toFile(dtFile *smgo.File) *File {
+	f := &File{
+		Type:                  "file",
+		LocationSpan:          toLocationSpan(dtFile.LocationSpan),
+		FooterSpan:            []int{dtFile.FooterSpan.Start, dtFile.FooterSpan.End},
+		ParsingErrorsDetected: len(dtFile.ParsingErrors) > 0,
+		Children:              make([]interface{}, 0, len(dtFile.Children)),
+		ParsingErrors:         make([]*ParsingError, 0, len(dtFile.ParsingErrors)),
+		LineEndings:           toLineEndings(dtFile.LineEndings),
+		FirstMixedLine:        dtFile.FirstMixedLine,
+	}
+	for _, child := range dtFile.Children {
+		node := toNode(child)
+		f.Children = append(f.Children, node)
+	}
+	for _, parsingError := range dtFile.ParsingErrors {
+		f.ParsingErrors = append(f.ParsingErrors, &ParsingError{
+			Location: []int{parsingError.Location.Line, parsingError.Location.Column},
+			Message:  parsingError.Message,
+		})
+	}
+	for _, warning := range dtFile.Warnings {
+		f.Warnings = append(f.Warnings, &Warning{
+			Location: []int{warning.Location.Line, warning.Location.Column},
+			Message:  warning.Message,
+		})
+	}
+	return f
+}
+
+func toNode(node smgo.Node) interface{} {
+	switch n := node.(type) {
+	case *smgo.Terminal:
+		return &Terminal{
+			Type:         toType(n),
+			Name:         n.Name,
+			ID:           n.ID,
+			Exported:     n.Exported,
+			Deprecated:   n.Deprecated,
+			LocationSpan: toLocationSpan(n.LocationSpan),
+			Span:         []int{n.Span.Start, n.Span.End},
+			Receiver:     n.Receiver,
+			Alias:        n.Alias,
+			Complexity:   n.Complexity,
+			Metrics:      toMetrics(n.Metrics),
+		}
+	case *smgo.Container:
+		c := &Container{
+			Type:         toType(n),
+			Name:         n.Name,
+			ID:           n.ID,
+			Exported:     n.Exported,
+			Deprecated:   n.Deprecated,
+			LocationSpan: toLocationSpan(n.LocationSpan),
+			HeaderSpan:   []int{n.HeaderSpan.Start, n.HeaderSpan.End},
+			FooterSpan:   []int{n.FooterSpan.Start, n.FooterSpan.End},
+			Children:     make([]interface{}, 0, len(n.Children)),
+			Metrics:      toMetrics(n.Metrics),
+		}
+		for _, child := range n.Children {
+			childNode := toNode(child)
+			c.Children = append(c.Children, childNode)
+		}
+		return c
+	default:
+		panic("unknown node type")
+	}
+}
+
+func toLocationSpan(ls smgo.LocationSpan) LocationSpan {
+	return LocationSpan{
+		Start: []int{ls.Start.Line, ls.Start.Column},
+		End:   []int{ls.End.Line, ls.End.Column},
+	}
+}
+
+func toMetrics(m *smgo.Metrics) *Metrics {
+	if m == nil {
+		return nil
+	}
+	return &Metrics{
+		Lines:        m.Lines,
+		CommentLines: m.CommentLines,
+		Bytes:        m.Bytes,
+	}
+}
+
+// toType returns the type written for node: its default type (see smgo.NodeTypeName), or the
+// one it's mapped to with -types.
+func toType(node smgo.Node) string {
+	return smgo.NodeTypeName(node, typeNames, false)
+}
+
+func toLineEndings(le smgo.LineEndings) string {
+	switch le {
+	case smgo.LFLineEndings:
+		return "LF"
+	case smgo.CRLFLineEndings:
+		return "CRLF"
+	default:
+		return "mixed"
+	}
+}
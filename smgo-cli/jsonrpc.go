@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// parseParams are the params of the "parse" method: the source code is read from Path, or
+// taken from Source when Path is empty.
+type parseParams struct {
+	Path        string `json:"path"`
+	Source      string `json:"source"`
+	Encoding    string `json:"encoding"`
+	IDs         bool   `json:"ids"`
+	Complexity  bool   `json:"complexity"`
+	Metrics     bool   `json:"metrics"`
+	Lossy       bool   `json:"lossy"`
+	InvalidUTF8 string `json:"invalidUTF8"`
+}
+
+// serveJSONRPC answers the JSON-RPC 2.0 requests read from r, writing the responses to w,
+// until r is exhausted. Single and batch requests are supported. The "parse" method returns
+// the declarations tree of a file; the "open", "edit", "tree" and "close" methods manage
+// sessions, which keep the source code and declarations tree of a file so edits are parsed
+// incrementally.
+//
+// Messages are plain JSON values, unless the first message starts with a "Content-Length"
+// header: in that case every message in both directions is framed with headers, as in the
+// Language Server Protocol, and messages longer than 64 MiB are answered with a parse error.
+func serveJSONRPC(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	prefix, _ := br.Peek(len(contentLengthHeader))
+	sessions := make(rpcSessions)
+	if strings.EqualFold(string(prefix), contentLengthHeader) {
+		return serveFramedJSONRPC(br, w, sessions)
+	}
+	decoder := json.NewDecoder(br)
+	encoder := json.NewEncoder(w)
+	for {
+		var msg json.RawMessage
+		err := decoder.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// the stream can't be resynchronized after a syntax error
+			return encoder.Encode(errorResponse(nil, rpcParseError, err.Error()))
+		}
+		response := handleJSONRPCMessage(msg, sessions)
+		if response == nil {
+			continue
+		}
+		err = encoder.Encode(response)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+const contentLengthHeader = "Content-Length:"
+
+// maxMessageLength is the maximum length of a framed message: the body of longer messages is
+// skipped without being read into memory.
+const maxMessageLength = 64 << 20
+
+// serveFramedJSONRPC is serveJSONRPC for messages framed with Content-Length headers.
+func serveFramedJSONRPC(r *bufio.Reader, w io.Writer, sessions rpcSessions) error {
+	for {
+		length := -1
+		for {
+			line, err := r.ReadString('\n')
+			if err == io.EOF && line == "" && length == -1 {
+				return nil
+			}
+			if err != nil {
+				return errors.Wrap(err, "error reading header")
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line == "" {
+				break
+			}
+			if len(line) > len(contentLengthHeader) && strings.EqualFold(line[:len(contentLengthHeader)], contentLengthHeader) {
+				length, err = strconv.Atoi(strings.TrimSpace(line[len(contentLengthHeader):]))
+				if err != nil || length < 0 {
+					return errors.Errorf("invalid header: %s", line)
+				}
+			}
+		}
+		if length == -1 {
+			return errors.New("missing Content-Length header")
+		}
+		var response interface{}
+		if length > maxMessageLength {
+			_, err := io.CopyN(ioutil.Discard, r, int64(length))
+			if err != nil {
+				return errors.Wrap(err, "error reading message")
+			}
+			response = errorResponse(nil, rpcParseError, fmt.Sprintf("message too large: %d bytes", length))
+		} else {
+			msg := make([]byte, length)
+			_, err := io.ReadFull(r, msg)
+			if err != nil {
+				return errors.Wrap(err, "error reading message")
+			}
+			if !json.Valid(msg) {
+				response = errorResponse(nil, rpcParseError, "invalid JSON")
+			} else {
+				response = handleJSONRPCMessage(msg, sessions)
+			}
+		}
+		if response == nil {
+			continue
+		}
+		body, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s %d\r\n\r\n%s", contentLengthHeader, len(body), body)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleJSONRPCMessage handles a single or batch request, returning the response to write
+// (nil if there is nothing to answer).
+func handleJSONRPCMessage(msg json.RawMessage, sessions rpcSessions) interface{} {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) == 0 || msg[0] != '[' {
+		response := handleJSONRPCRequest(msg, sessions)
+		if response == nil {
+			return nil
+		}
+		return response
+	}
+	var batch []json.RawMessage
+	err := json.Unmarshal(msg, &batch)
+	if err != nil || len(batch) == 0 {
+		return errorResponse(nil, rpcInvalidRequest, "invalid batch")
+	}
+	responses := make([]*rpcResponse, 0, len(batch))
+	for _, req := range batch {
+		response := handleJSONRPCRequest(req, sessions)
+		if response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+func handleJSONRPCRequest(msg json.RawMessage, sessions rpcSessions) *rpcResponse {
+	var req rpcRequest
+	err := json.Unmarshal(msg, &req)
+	if err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(nil, rpcInvalidRequest, "invalid request")
+	}
+	result, rpcErr := callJSONRPCMethod(&req, sessions)
+	// notifications aren't answered
+	if req.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr.Code, rpcErr.Message)
+	}
+	return &rpcResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
+	}
+}
+
+// callJSONRPCMethod calls the method of req. A panic is recovered as a server error, so a
+// file that can't be handled doesn't end the server (and the open sessions).
+func callJSONRPCMethod(req *rpcRequest, sessions rpcSessions) (result interface{}, rpcErr *rpcError) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, rpcErr = nil, &rpcError{Code: rpcServerError, Message: fmt.Sprintf("internal error: %v", r)}
+		}
+	}()
+	switch req.Method {
+	case "parse":
+		result, rpcErr = rpcParse(req.Params)
+	case "open":
+		result, rpcErr = sessions.Open(req.Params)
+	case "edit":
+		result, rpcErr = sessions.Edit(req.Params)
+	case "tree":
+		result, rpcErr = sessions.Tree(req.Params)
+	case "close":
+		result, rpcErr = sessions.Close(req.Params)
+	default:
+		rpcErr = &rpcError{Code: rpcMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	return result, rpcErr
+}
+
+func rpcParse(rawParams json.RawMessage) (interface{}, *rpcError) {
+	var params parseParams
+	err := json.Unmarshal(rawParams, &params)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	if params.Encoding == "" {
+		params.Encoding = "UTF-8"
+	}
+	var src io.Reader
+	if params.Path != "" {
+		srcFile, err := os.Open(params.Path)
+		if err != nil {
+			return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+		}
+		defer srcFile.Close()
+		src = srcFile
+	} else {
+		src = strings.NewReader(params.Source)
+	}
+	// the options of the shell, overridden by the parameters
+	opts, err := parseOptions(params.Path)
+	if err != nil {
+		return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+	}
+	if params.InvalidUTF8 != "" {
+		if _, ok := invalidUTF8Policies[params.InvalidUTF8]; !ok {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid invalidUTF8: " + params.InvalidUTF8}
+		}
+		opts = append(opts, smgo.WithInvalidUTF8Policy(invalidUTF8Policy(params.InvalidUTF8)))
+	}
+	if params.IDs {
+		opts = append(opts, smgo.WithStableIDs())
+	}
+	if params.Complexity {
+		opts = append(opts, smgo.WithComplexity())
+	}
+	if params.Metrics {
+		opts = append(opts, smgo.WithMetrics())
+	}
+	if params.Lossy {
+		opts = append(opts, smgo.WithLossyDecoding(utf8.RuneError))
+	}
+	dtFile, err := smgo.Parse(src, params.Encoding, opts...)
+	if err != nil {
+		return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+	}
+	file := toFile(dtFile)
+	file.Name = params.Path
+	return file, nil
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &rpcError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
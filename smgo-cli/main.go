@@ -2,22 +2,95 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/jriquelme/SemanticMergeGO/smgo"
-	"gopkg.in/yaml.v2"
+)
+
+const usage = "invalid arguments: use smgo-cli [flags] shell <flag file path>, smgo-cli -jsonrpc, smgo-cli selftest, smgo-cli install-config [config dir...], smgo-cli manifest [-o output] <pattern>..., smgo-cli sarif [-o output] <pattern>... or smgo-cli index [-o index] <pattern>..."
+
+var (
+	ids         = flag.Bool("ids", false, "emit a stable id for every declaration")
+	jsonrpc     = flag.Bool("jsonrpc", false, "serve JSON-RPC 2.0 requests over stdin/stdout")
+	lossy       = flag.Bool("lossy", false, "replace invalid or undecodable bytes instead of failing")
+	invalidUTF8 = flag.String("invalid-utf8", "error", "handling of invalid UTF-8: error, replace or passthrough")
+	timings     = flag.Bool("timings", false, "log the duration of the phases of the parses in shell mode to stderr")
+	strict      = flag.Bool("strict", false, "fail on declarations trees violating the SemanticMerge specification")
+	encoded     = flag.Bool("encoded-offsets", true, "make the spans of files not in UTF-8 count the bytes of the file as encoded, as SemanticMerge reads them")
+	schema      = flag.Int("schema", smgo.Schema1, "version of the schema of the YAML output: 1, 2 to add the exported, deprecated and receiver fields, or 3 to write the type aliases as Alias")
+	types       = flag.String("types", "", "YAML file mapping the default types of the nodes to the types written instead")
+	methods     = flag.Bool("group-methods", false, "group the methods following their types under them")
 )
 
 func main() {
-	if len(os.Args) != 3 {
-		log.Fatalln("invalid arguments: use smgo-cli shell <flag file path>")
+	flag.Parse()
+	args := flag.Args()
+	if _, ok := invalidUTF8Policies[*invalidUTF8]; !ok {
+		log.Fatalf("invalid -invalid-utf8 value: %s", *invalidUTF8)
+	}
+	if *schema < smgo.Schema1 || *schema > smgo.LatestSchema {
+		log.Fatalf("invalid -schema value: %d", *schema)
+	}
+	if *types != "" {
+		var err error
+		typeNames, err = loadTypeNames(*types)
+		if err != nil {
+			log.Fatalf("invalid -types file: %s", err)
+		}
+	}
+	if *jsonrpc {
+		err := serveJSONRPC(os.Stdin, os.Stdout)
+		if err != nil {
+			log.Fatalf("error serving JSON-RPC: %s", err)
+		}
+		return
+	}
+	if len(args) == 1 && args[0] == "selftest" {
+		err := selftest(os.Stdout)
+		if err != nil {
+			log.Fatalf("selftest failed: %s", err)
+		}
+		return
 	}
-	if os.Args[1] != "shell" {
-		log.Fatalln("invalid arguments: use smgo-cli shell <flag file path>")
+	if len(args) >= 1 && args[0] == "install-config" {
+		err := installConfig(os.Stdout, args[1:])
+		if err != nil {
+			log.Fatalf("install-config failed: %s", err)
+		}
+		return
 	}
-	flagFilePath := os.Args[2]
+	if len(args) >= 1 && args[0] == "manifest" {
+		err := manifest(args[1:], os.Stdout)
+		if err != nil {
+			log.Fatalf("manifest failed: %s", err)
+		}
+		return
+	}
+	if len(args) >= 1 && args[0] == "sarif" {
+		err := sarif(args[1:], os.Stdout)
+		if err != nil {
+			log.Fatalf("sarif failed: %s", err)
+		}
+		return
+	}
+	if len(args) >= 1 && args[0] == "index" {
+		err := index(args[1:], os.Stdout)
+		if err != nil {
+			log.Fatalf("index failed: %s", err)
+		}
+		return
+	}
+	if len(args) != 2 {
+		log.Fatalln(usage)
+	}
+	if args[0] != "shell" {
+		log.Fatalln(usage)
+	}
+	flagFilePath := args[1]
 	flagFile, err := os.Create(flagFilePath)
 	if err != nil {
 		log.Fatalf("error creating flag file: %s", err)
@@ -48,6 +121,9 @@ func main() {
 
 		err := parse(srcOrEnd, encoding, output)
 		if err != nil {
+			if *strict {
+				log.Printf("error parsing %s: %s", srcOrEnd, err)
+			}
 			fmt.Println("KO")
 		} else {
 			fmt.Println("OK")
@@ -62,7 +138,11 @@ func parse(src, encoding, output string) error {
 	}
 	defer srcFile.Close()
 
-	dtFile, err := smgo.Parse(srcFile, encoding)
+	opts, err := parseOptions(src)
+	if err != nil {
+		return err
+	}
+	dtFile, err := smgo.Parse(srcFile, encoding, opts...)
 	if err != nil {
 		return err
 	}
@@ -71,10 +151,45 @@ func parse(src, encoding, output string) error {
 		return err
 	}
 	defer outputFile.Close()
-	yamlFile := toFile(dtFile)
-	yamlFile.Name = src
+	start := time.Now()
+	err = smgo.Write(outputFile, src, dtFile, smgo.WithSchema(*schema), smgo.WithTypeNames(typeNames))
+	if err != nil {
+		return err
+	}
+	if dtFile.Stats != nil {
+		logTimings(src, dtFile.Stats, time.Since(start))
+	}
+	return nil
+}
+
+// logTimings logs the durations of the phases of the parse of src, and the time spent
+// serializing its declarations tree.
+func logTimings(src string, stats *smgo.Stats, serialize time.Duration) {
+	log.Printf("timings of %s: decode %s, preprocess %s, parse %s, visit %s, fix %s, total %s, serialize %s",
+		src, stats.Decode, stats.Preprocess, stats.Parse, stats.Visit, stats.Fix, stats.Total, serialize)
+}
+
+// parseOptions returns the parse options of the flags, overridden by the profile rule of the
+// file in path (see Profile), if any.
+func parseOptions(path string) ([]smgo.Option, error) {
+	if path == "" {
+		return (*ProfileRule)(nil).options(), nil
+	}
+	rule, err := profileRule(path)
+	if err != nil {
+		return nil, err
+	}
+	return rule.options(), nil
+}
+
+var invalidUTF8Policies = map[string]smgo.InvalidUTF8Policy{
+	"error":       smgo.InvalidUTF8Error,
+	"replace":     smgo.InvalidUTF8Replace,
+	"passthrough": smgo.InvalidUTF8PassThrough,
+}
 
-	yamlEncoder := yaml.NewEncoder(outputFile)
-	defer yamlEncoder.Close()
-	return yamlEncoder.Encode(yamlFile)
+// invalidUTF8Policy returns the policy named name, or smgo.InvalidUTF8Error if name is
+// unknown.
+func invalidUTF8Policy(name string) smgo.InvalidUTF8Policy {
+	return invalidUTF8Policies[name]
 }
@@ -1,3 +1,4 @@
+//go:build itest
 // +build itest
 
 package main_test
@@ -5,13 +6,18 @@ package main_test
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -97,3 +103,676 @@ func TestSmgoCli(t *testing.T) {
 		}
 	}
 }
+
+func TestSmgoCliTimings(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	output := filepath.Join(os.TempDir(), "simple_func_timings.yaml")
+	defer os.Remove(output)
+
+	cmd := exec.Command(cli, "-timings", "shell", "flag-file")
+	defer os.Remove("flag-file")
+	cmd.Stdin = strings.NewReader("testdata/simple_func.go" + newLine + "UTF-8" + newLine + output + newLine + "end" + newLine)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	require.Nil(t, err)
+	assert.Equal(t, "OK"+newLine, string(stdout))
+	assert.Contains(t, stderr.String(), "timings of testdata/simple_func.go: decode ")
+	assert.Contains(t, stderr.String(), ", serialize ")
+}
+
+func TestSmgoCliTypes(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-types")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	types := filepath.Join(dir, "types.yaml")
+	err = ioutil.WriteFile(types, []byte("Function: method\nPackage: namespace\n"), 0644)
+	require.Nil(t, err)
+	output := filepath.Join(dir, "simple_func.yaml")
+
+	cmd := exec.Command(cli, "-types", types, "shell", filepath.Join(dir, "flag-file"))
+	cmd.Stdin = strings.NewReader("testdata/simple_func.go" + newLine + "UTF-8" + newLine + output + newLine + "end" + newLine)
+	stdout, err := cmd.Output()
+	require.Nil(t, err)
+	assert.Equal(t, "OK"+newLine, string(stdout))
+	yamlOutput, err := ioutil.ReadFile(output)
+	require.Nil(t, err)
+	expected, err := ioutil.ReadFile("testdata/simple_func.yaml")
+	require.Nil(t, err)
+	expected = bytes.Replace(expected, []byte("type: Function"), []byte("type: method"), 1)
+	expected = bytes.Replace(expected, []byte("type: Package"), []byte("type: namespace"), 1)
+	assert.Equal(t, string(expected), string(yamlOutput))
+
+	// unknown types are rejected
+	err = ioutil.WriteFile(types, []byte("Class: struct\n"), 0644)
+	require.Nil(t, err)
+	out, err := exec.Command(cli, "-types", types, "shell", filepath.Join(dir, "flag-file")).CombinedOutput()
+	assert.NotNil(t, err)
+	assert.Contains(t, string(out), "unknown type Class")
+}
+
+func TestSmgoCliSchema(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-schema")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "simple_func.yaml")
+
+	cmd := exec.Command(cli, "-schema", "2", "shell", filepath.Join(dir, "flag-file"))
+	cmd.Stdin = strings.NewReader("testdata/simple_func.go" + newLine + "UTF-8" + newLine + output + newLine + "end" + newLine)
+	stdout, err := cmd.Output()
+	require.Nil(t, err)
+	assert.Equal(t, "OK"+newLine, string(stdout))
+	yamlOutput, err := ioutil.ReadFile(output)
+	require.Nil(t, err)
+	expected, err := ioutil.ReadFile("testdata/simple_func.yaml")
+	require.Nil(t, err)
+	expected = bytes.Replace(expected, []byte("name: Hi\n"), []byte("name: Hi\n  exported: true\n"), 1)
+	assert.Equal(t, string(expected), string(yamlOutput))
+
+	out, err := exec.Command(cli, "-schema", "4", "shell", filepath.Join(dir, "flag-file")).CombinedOutput()
+	assert.NotNil(t, err)
+	assert.Contains(t, string(out), "invalid -schema value: 4")
+}
+
+func TestSmgoCliAliases(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-aliases")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "alias.go")
+	err = ioutil.WriteFile(src, []byte("package alias\n\ntype a = b\n\ntype b int\n"), 0644)
+	require.Nil(t, err)
+	types := filepath.Join(dir, "types.yaml")
+	err = ioutil.WriteFile(types, []byte("Alias: alias\n"), 0644)
+	require.Nil(t, err)
+	output := filepath.Join(dir, "alias.yaml")
+	// unexported, so the schema 3 doesn't add fields
+	expected := `type: file
+name: ` + src + `
+locationSpan: {start: [1, 0], end: [5, 11]}
+footerSpan: [0, -1]
+parsingErrorsDetected: false
+children:
+- type: Package
+  name: alias
+  locationSpan: {start: [1, 0], end: [1, 14]}
+  span: [0, 13]
+- type: %s
+  name: a
+  locationSpan: {start: [2, 0], end: [3, 11]}
+  span: [14, 25]
+- type: Type
+  name: b
+  locationSpan: {start: [4, 0], end: [5, 11]}
+  span: [26, 37]
+`
+
+	for _, c := range []struct {
+		args []string
+		typ  string
+	}{
+		{nil, "Type"},
+		{[]string{"-schema", "3"}, "Alias"},
+		{[]string{"-types", types}, "alias"},
+	} {
+		args := append(c.args, "shell", filepath.Join(dir, "flag-file"))
+		cmd := exec.Command(cli, args...)
+		cmd.Stdin = strings.NewReader(src + newLine + "UTF-8" + newLine + output + newLine + "end" + newLine)
+		stdout, err := cmd.Output()
+		require.Nil(t, err)
+		assert.Equal(t, "OK"+newLine, string(stdout))
+		yamlOutput, err := ioutil.ReadFile(output)
+		require.Nil(t, err)
+		assert.Equal(t, fmt.Sprintf(expected, c.typ), string(yamlOutput), "%v", c.args)
+	}
+
+	// the mapping applies to every output
+	request := `{"jsonrpc": "2.0", "id": 1, "method": "parse", "params": {"path": ` + strconv.Quote(src) + `}}`
+	cmd := exec.Command(cli, "-types", types, "-jsonrpc")
+	cmd.Stdin = strings.NewReader(request)
+	out, err := cmd.Output()
+	require.Nil(t, err)
+	assert.Contains(t, string(out), `{"type":"alias","name":"a",`)
+	assert.Contains(t, string(out), `{"type":"Type","name":"b",`)
+	out, err = exec.Command(cli, "-types", types, "manifest", dir).Output()
+	require.Nil(t, err)
+	assert.Contains(t, string(out), `"type": "alias"`)
+	index := filepath.Join(dir, "index.json")
+	_, err = exec.Command(cli, "-types", types, "index", "-o", index, dir).Output()
+	require.Nil(t, err)
+	out, err = ioutil.ReadFile(index)
+	require.Nil(t, err)
+	assert.Contains(t, string(out), `"type": "alias"`)
+}
+
+func TestSmgoCliGroupMethods(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-methods")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "methods.go")
+	err = ioutil.WriteFile(src, []byte("package methods\n\ntype T struct{}\n\nfunc (T) M() {}\n"), 0644)
+	require.Nil(t, err)
+	output := filepath.Join(dir, "methods.yaml")
+	expected := `type: file
+name: ` + src + `
+locationSpan: {start: [1, 0], end: [5, 16]}
+footerSpan: [0, -1]
+parsingErrorsDetected: false
+children:
+- type: Package
+  name: methods
+  locationSpan: {start: [1, 0], end: [1, 16]}
+  span: [0, 15]
+- type: Struct
+  name: T
+  locationSpan: {start: [2, 0], end: [5, 16]}
+  headerSpan: [16, 32]
+  footerSpan: [50, 49]
+  children:
+  - type: Function
+    name: M
+    locationSpan: {start: [4, 0], end: [5, 16]}
+    span: [33, 49]
+`
+	cmd := exec.Command(cli, "-group-methods", "shell", filepath.Join(dir, "flag-file"))
+	cmd.Stdin = strings.NewReader(src + newLine + "UTF-8" + newLine + output + newLine + "end" + newLine)
+	stdout, err := cmd.Output()
+	require.Nil(t, err)
+	assert.Equal(t, "OK"+newLine, string(stdout))
+	yamlOutput, err := ioutil.ReadFile(output)
+	require.Nil(t, err)
+	assert.Equal(t, expected, string(yamlOutput))
+
+	// or set by a profile
+	err = ioutil.WriteFile(filepath.Join(dir, ".smgo.yaml"), []byte("rules:\n- path: ...\n  groupMethods: true\n"), 0644)
+	require.Nil(t, err)
+	cmd = exec.Command(cli, "shell", filepath.Join(dir, "flag-file"))
+	cmd.Stdin = strings.NewReader(src + newLine + "UTF-8" + newLine + output + newLine + "end" + newLine)
+	stdout, err = cmd.Output()
+	require.Nil(t, err)
+	assert.Equal(t, "OK"+newLine, string(stdout))
+	yamlOutput, err = ioutil.ReadFile(output)
+	require.Nil(t, err)
+	assert.Equal(t, expected, string(yamlOutput))
+
+	// the grouped methods are indexed with their receivers
+	index := filepath.Join(dir, "index.json")
+	_, err = exec.Command(cli, "index", "-o", index, dir).Output()
+	require.Nil(t, err)
+	out, err := ioutil.ReadFile(index)
+	require.Nil(t, err)
+	assert.Contains(t, string(out), `"name": "T.M"`)
+}
+
+func TestSmgoCliEncodedOffsets(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-encoded")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	src, err := ioutil.ReadFile("testdata/simple_func.go")
+	require.Nil(t, err)
+	// UTF-16LE, with BOM: every ASCII byte takes two bytes
+	utf16Src := []byte{0xff, 0xfe}
+	for _, b := range src {
+		utf16Src = append(utf16Src, b, 0)
+	}
+	source := filepath.Join(dir, "simple_func.go")
+	err = ioutil.WriteFile(source, utf16Src, 0644)
+	require.Nil(t, err)
+	output := filepath.Join(dir, "simple_func.yaml")
+
+	cmd := exec.Command(cli, "shell", filepath.Join(dir, "flag-file"))
+	cmd.Stdin = strings.NewReader(source + newLine + "UTF-16LE" + newLine + output + newLine + "end" + newLine)
+	stdout, err := cmd.Output()
+	require.Nil(t, err)
+	assert.Equal(t, "OK"+newLine, string(stdout))
+	yamlOutput, err := ioutil.ReadFile(output)
+	require.Nil(t, err)
+	expected := `type: file
+name: ` + source + `
+locationSpan: {start: [1, 0], end: [5, 4]}
+footerSpan: [0, -1]
+parsingErrorsDetected: false
+children:
+- type: Package
+  name: simplefunc
+  locationSpan: {start: [1, 0], end: [1, 40]}
+  span: [0, 39]
+- type: Function
+  name: Hi
+  locationSpan: {start: [2, 0], end: [5, 4]}
+  span: [40, 97]
+`
+	assert.Equal(t, expected, string(yamlOutput))
+
+	// JSON-RPC parses with the options of the shell
+	request := `{"jsonrpc": "2.0", "id": 1, "method": "parse", "params": {"path": ` + strconv.Quote(source) + `, "encoding": "UTF-16LE"}}`
+	cmd = exec.Command(cli, "-jsonrpc")
+	cmd.Stdin = strings.NewReader(request)
+	out, err := cmd.Output()
+	require.Nil(t, err)
+	expectedOutput := `{"jsonrpc":"2.0","id":1,"result":{"type":"file","name":` + strconv.Quote(source) + `,"locationSpan":{"start":[1,0],"end":[5,4]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"simplefunc","locationSpan":{"start":[1,0],"end":[1,40]},"span":[0,39]},{"type":"Function","name":"Hi","exported":true,"locationSpan":{"start":[2,0],"end":[5,4]},"span":[40,97]}],"lineEndings":"LF"}}
+`
+	assert.Equal(t, expectedOutput, string(out))
+}
+
+func TestSmgoCliSelftest(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	output, err := exec.Command(cli, "selftest").CombinedOutput()
+	assert.Nil(t, err)
+	t.Logf("selftest output:\n%s", output)
+}
+
+func TestSmgoCliJSONRPC(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	requests := `{"jsonrpc": "2.0", "id": 1, "method": "parse", "params": {"path": "testdata/simple_func.go"}}
+{"jsonrpc": "2.0", "id": 2, "method": "parse", "params": {"source": "package main", "encoding": "Shift_JIS"}}
+{"jsonrpc": "2.0", "id": 3, "method": "merge"}
+{"jsonrpc": "2.0", "method": "parse", "params": {"source": "package main\n"}}
+[{"jsonrpc": "2.0", "id": 4, "method": "parse", "params": {"source": "package main\n"}}, {"id": 5}]
+`
+	cmd := exec.Command(cli, "-jsonrpc")
+	cmd.Stdin = bytes.NewBufferString(requests)
+	output, err := cmd.Output()
+	require.Nil(t, err)
+
+	expectedOutput := `{"jsonrpc":"2.0","id":1,"result":{"type":"file","name":"testdata/simple_func.go","locationSpan":{"start":[1,0],"end":[5,2]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"simplefunc","locationSpan":{"start":[1,0],"end":[1,19]},"span":[0,18]},{"type":"Function","name":"Hi","exported":true,"locationSpan":{"start":[2,0],"end":[5,2]},"span":[19,47]}],"lineEndings":"LF"}}
+{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"Unsupported encoding"}}
+{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"method not found: merge"}}
+[{"jsonrpc":"2.0","id":4,"result":{"type":"file","name":"","locationSpan":{"start":[1,0],"end":[1,13]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"main","locationSpan":{"start":[1,0],"end":[1,13]},"span":[0,12]}],"lineEndings":"LF"}},{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}]
+`
+	assert.Equal(t, expectedOutput, string(output))
+}
+
+func TestSmgoCliJSONRPCFramed(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	request1 := `{"jsonrpc": "2.0", "id": 1, "method": "parse", "params": {"source": "package main\n"}}`
+	request2 := `{"jsonrpc": "2.0", "id": 2, "method": "diff"}`
+	requests := "Content-Length: " + strconv.Itoa(len(request1)) + "\r\n\r\n" + request1 +
+		"content-length: " + strconv.Itoa(len(request2)) + "\r\nContent-Type: application/json\r\n\r\n" + request2
+	cmd := exec.Command(cli, "-jsonrpc")
+	cmd.Stdin = bytes.NewBufferString(requests)
+	output, err := cmd.Output()
+	require.Nil(t, err)
+
+	response1 := `{"jsonrpc":"2.0","id":1,"result":{"type":"file","name":"","locationSpan":{"start":[1,0],"end":[1,13]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"main","locationSpan":{"start":[1,0],"end":[1,13]},"span":[0,12]}],"lineEndings":"LF"}}`
+	response2 := `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found: diff"}}`
+	expectedOutput := "Content-Length: " + strconv.Itoa(len(response1)) + "\r\n\r\n" + response1 +
+		"Content-Length: " + strconv.Itoa(len(response2)) + "\r\n\r\n" + response2
+	assert.Equal(t, expectedOutput, string(output))
+}
+
+func TestSmgoCliJSONRPCFramedTooLarge(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	// the body of the message is skipped, so the next one is read
+	length := 64<<20 + 1
+	request2 := `{"jsonrpc": "2.0", "id": 2, "method": "diff"}`
+	requests := "Content-Length: " + strconv.Itoa(length) + "\r\n\r\n" + strings.Repeat(" ", length) +
+		"Content-Length: " + strconv.Itoa(len(request2)) + "\r\n\r\n" + request2
+	cmd := exec.Command(cli, "-jsonrpc")
+	cmd.Stdin = bytes.NewBufferString(requests)
+	output, err := cmd.Output()
+	require.Nil(t, err)
+
+	response1 := `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"message too large: 67108865 bytes"}}`
+	response2 := `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found: diff"}}`
+	expectedOutput := "Content-Length: " + strconv.Itoa(len(response1)) + "\r\n\r\n" + response1 +
+		"Content-Length: " + strconv.Itoa(len(response2)) + "\r\n\r\n" + response2
+	assert.Equal(t, expectedOutput, string(output))
+}
+
+func TestSmgoCliInstallConfig(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-install-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	conf := filepath.Join(dir, "externalparsers.conf")
+	err = ioutil.WriteFile(conf, []byte(".cs=csparser.exe"+newLine+".go=old-parser"+newLine), 0644)
+	require.Nil(t, err)
+
+	output, err := exec.Command(cli, "install-config", dir).CombinedOutput()
+	require.Nil(t, err, string(output))
+	cliPath, err := filepath.EvalSymlinks(cli)
+	require.Nil(t, err)
+	content, err := ioutil.ReadFile(conf)
+	require.Nil(t, err)
+	assert.Equal(t, ".cs=csparser.exe"+newLine+".go="+cliPath+newLine, string(content))
+}
+
+func TestSmgoCliManifest(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	output := filepath.Join(os.TempDir(), "manifest.json")
+	defer os.Remove(output)
+
+	out, err := exec.Command(cli, "manifest", "testdata/...", "-o", output).CombinedOutput()
+	require.Nil(t, err, string(out))
+	manifest, err := ioutil.ReadFile(output)
+	require.Nil(t, err)
+	t.Logf("manifest:\n%s", manifest)
+	assert.Contains(t, string(manifest), `"path": "testdata/simple_func.go"`)
+	assert.Contains(t, string(manifest), `"name": "Hi"`)
+
+	// the manifest is deterministic
+	stdout, err := exec.Command(cli, "manifest", "testdata/simple_func.go", "testdata").Output()
+	require.Nil(t, err)
+	assert.Equal(t, string(manifest), string(stdout))
+}
+
+func TestSmgoCliManifestBuildTags(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-buildtags")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	srcs := map[string]string{
+		"a.go":       "package a\n",
+		"a_linux.go": "package a\n",
+		"b.go":       "//go:build windows || (darwin && cgo)\n\npackage a\n",
+		"c.go":       "// Copyright notice.\n\n// +build custom\n\npackage a\n",
+	}
+	for name, src := range srcs {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644)
+		require.Nil(t, err)
+	}
+
+	cases := []struct {
+		Args     []string
+		Build    map[string]interface{}
+		Expected map[string]string
+	}{
+		{nil, nil, map[string]string{"a.go": "", "a_linux.go": "", "b.go": "windows || (darwin && cgo)", "c.go": "custom"}},
+		{[]string{"-goos", "linux", "-goarch", "amd64"}, map[string]interface{}{"goos": "linux", "goarch": "amd64"},
+			map[string]string{"a.go": "", "a_linux.go": ""}},
+		{[]string{"-goos", "windows", "-goarch", "386", "-tags", "custom"},
+			map[string]interface{}{"goos": "windows", "goarch": "386", "tags": []interface{}{"custom"}},
+			map[string]string{"a.go": "", "b.go": "windows || (darwin && cgo)", "c.go": "custom"}},
+	}
+	for _, c := range cases {
+		out, err := exec.Command(cli, append([]string{"manifest", dir}, c.Args...)...).Output()
+		require.Nil(t, err)
+		var manifest struct {
+			Build map[string]interface{} `json:"build"`
+			Files []struct {
+				Path       string `json:"path"`
+				Constraint string `json:"constraint"`
+			} `json:"files"`
+		}
+		err = json.Unmarshal(out, &manifest)
+		require.Nil(t, err)
+		assert.Equal(t, c.Build, manifest.Build, "%v", c.Args)
+		files := make(map[string]string)
+		for _, file := range manifest.Files {
+			files[filepath.Base(file.Path)] = file.Constraint
+		}
+		assert.Equal(t, c.Expected, files, "%v", c.Args)
+	}
+}
+
+func TestSmgoCliShellProfileChanges(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-profile")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "a.go")
+	err = ioutil.WriteFile(src, []byte("package a\n"), 0644)
+	require.Nil(t, err)
+	profile := filepath.Join(dir, ".smgo.yaml")
+	err = ioutil.WriteFile(profile, []byte("rules:\n- path: ...\n  ids: true\n"), 0644)
+	require.Nil(t, err)
+	output := filepath.Join(dir, "a.yaml")
+
+	cmd := exec.Command(cli, "shell", filepath.Join(dir, "flag-file"))
+	wc, err := cmd.StdinPipe()
+	require.Nil(t, err)
+	rc, err := cmd.StdoutPipe()
+	require.Nil(t, err)
+	scanner := bufio.NewScanner(rc)
+	err = cmd.Start()
+	require.Nil(t, err)
+	parse := func() string {
+		io.WriteString(wc, src+newLine+"UTF-8"+newLine+output+newLine)
+		require.True(t, scanner.Scan(), "%v", scanner.Err())
+		require.Equal(t, "OK", scanner.Text())
+		yamlOutput, err := ioutil.ReadFile(output)
+		require.Nil(t, err)
+		return string(yamlOutput)
+	}
+
+	assert.Contains(t, parse(), "id: ")
+	// the shell reads the profile again once changed
+	err = ioutil.WriteFile(profile, []byte("rules:\n- path: ...\n  ids: false\n"), 0644)
+	require.Nil(t, err)
+	later := time.Now().Add(time.Hour)
+	err = os.Chtimes(profile, later, later)
+	require.Nil(t, err)
+	assert.NotContains(t, parse(), "id: ")
+	err = os.Remove(profile)
+	require.Nil(t, err)
+	assert.NotContains(t, parse(), "id: ")
+
+	io.WriteString(wc, "end"+newLine)
+	err = cmd.Wait()
+	require.Nil(t, err)
+}
+
+func TestSmgoCliManifestProfile(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-profile")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	err = os.Mkdir(filepath.Join(dir, "gen"), 0755)
+	require.Nil(t, err)
+	invalid := "package a\n\n// invalid \xff\n"
+	srcs := map[string]string{
+		".smgo.yaml":      "rules:\n- path: \"*.pb.go\"\n  skip: true\n- path: gen/...\n  invalidUTF8: passthrough\n- path: ...\n  tests: false\n",
+		"a.go":            invalid,
+		"a_test.go":       "package a\n",
+		"a.pb.go":         "package a\n",
+		"gen/gen.go":      invalid,
+		"gen/gen_test.go": "package gen\n",
+		"gen/other.pb.go": "package gen\n",
+		"gen/sub/sub.go":  invalid,
+	}
+	err = os.MkdirAll(filepath.Join(dir, "gen", "sub"), 0755)
+	require.Nil(t, err)
+	for name, src := range srcs {
+		err = ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(src), 0644)
+		require.Nil(t, err)
+	}
+
+	out, err := exec.Command(cli, "manifest", dir+"/...").Output()
+	require.Nil(t, err)
+	var manifest struct {
+		Files []struct {
+			Path                  string `json:"path"`
+			ParsingErrorsDetected bool   `json:"parsingErrorsDetected"`
+		} `json:"files"`
+	}
+	err = json.Unmarshal(out, &manifest)
+	require.Nil(t, err)
+	files := make(map[string]bool)
+	for _, file := range manifest.Files {
+		rel, err := filepath.Rel(dir, file.Path)
+		require.Nil(t, err)
+		files[filepath.ToSlash(rel)] = file.ParsingErrorsDetected
+	}
+	assert.Equal(t, map[string]bool{
+		"a.go":            true,
+		"gen/gen.go":      false,
+		"gen/gen_test.go": false,
+		"gen/sub/sub.go":  false,
+	}, files)
+
+	// invalid profiles are reported
+	err = ioutil.WriteFile(filepath.Join(dir, ".smgo.yaml"), []byte("rules:\n- path: ...\n  invalidUTF8: ignore\n"), 0644)
+	require.Nil(t, err)
+	out, err = exec.Command(cli, "manifest", dir).CombinedOutput()
+	assert.NotNil(t, err)
+	assert.Contains(t, string(out), "invalid invalidUTF8 value ignore")
+}
+
+func TestSmgoCliSarif(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-sarif")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "invalid.go"), []byte("package invalid\n\nfunc A( {\n}\n"), 0644)
+	require.Nil(t, err)
+
+	output, err := exec.Command(cli, "sarif", "testdata", dir).Output()
+	require.Nil(t, err)
+	t.Logf("sarif:\n%s", output)
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	err = json.Unmarshal(output, &sarif)
+	require.Nil(t, err)
+	assert.Equal(t, "2.1.0", sarif.Version)
+	require.Len(t, sarif.Runs, 1)
+	require.Len(t, sarif.Runs[0].Results, 1)
+	result := sarif.Runs[0].Results[0]
+	assert.Equal(t, "parsing-error", result.RuleID)
+	assert.Equal(t, filepath.ToSlash(filepath.Join(dir, "invalid.go")), result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+}
+
+func TestSmgoCliJSONRPCSessions(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	requests := `{"jsonrpc": "2.0", "id": 1, "method": "open", "params": {"id": "a.go", "source": "package main\n"}}
+{"jsonrpc": "2.0", "id": 2, "method": "edit", "params": {"id": "a.go", "edits": [{"start": 13, "end": 13, "newText": "\nfunc A() {}\n"}]}}
+{"jsonrpc": "2.0", "id": 3, "method": "edit", "params": {"id": "a.go", "edits": [{"start": 19, "end": 20, "newText": "Hi"}]}}
+{"jsonrpc": "2.0", "id": 4, "method": "edit", "params": {"id": "a.go", "edits": [{"start": 20, "end": 19, "newText": ""}]}}
+{"jsonrpc": "2.0", "id": 5, "method": "close", "params": {"id": "a.go"}}
+{"jsonrpc": "2.0", "id": 6, "method": "tree", "params": {"id": "a.go"}}
+{"jsonrpc": "2.0", "id": 7, "method": "open", "params": {"id": "b.go", "source": "package main\n\ntype T struct {\n\tio.Reader\n}\n"}}
+{"jsonrpc": "2.0", "id": 8, "method": "tree", "params": {"id": "b.go"}}
+`
+	cmd := exec.Command(cli, "-jsonrpc")
+	cmd.Stdin = bytes.NewBufferString(requests)
+	output, err := cmd.Output()
+	require.Nil(t, err)
+
+	expectedOutput := `{"jsonrpc":"2.0","id":1,"result":{"type":"file","name":"a.go","locationSpan":{"start":[1,0],"end":[1,13]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"main","locationSpan":{"start":[1,0],"end":[1,13]},"span":[0,12]}],"lineEndings":"LF"}}
+{"jsonrpc":"2.0","id":2,"result":{"type":"file","name":"a.go","locationSpan":{"start":[1,0],"end":[3,12]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"main","locationSpan":{"start":[1,0],"end":[1,13]},"span":[0,12]},{"type":"Function","name":"A","exported":true,"locationSpan":{"start":[2,0],"end":[3,12]},"span":[13,25]}],"lineEndings":"LF"}}
+{"jsonrpc":"2.0","id":3,"result":{"type":"file","name":"a.go","locationSpan":{"start":[1,0],"end":[3,13]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"main","locationSpan":{"start":[1,0],"end":[1,13]},"span":[0,12]},{"type":"Function","name":"Hi","exported":true,"locationSpan":{"start":[2,0],"end":[3,13]},"span":[13,26]}],"lineEndings":"LF"}}
+{"jsonrpc":"2.0","id":4,"error":{"code":-32602,"message":"Invalid text edits"}}
+{"jsonrpc":"2.0","id":5,"result":true}
+{"jsonrpc":"2.0","id":6,"error":{"code":-32602,"message":"unknown session: a.go"}}
+{"jsonrpc":"2.0","id":7,"result":{"type":"file","name":"b.go","locationSpan":{"start":[1,0],"end":[5,2]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"main","locationSpan":{"start":[1,0],"end":[1,13]},"span":[0,12]},{"type":"Struct","name":"T","exported":true,"locationSpan":{"start":[2,0],"end":[5,2]},"headerSpan":[13,29],"footerSpan":[41,42],"children":[{"type":"Field","name":"io.Reader","exported":true,"locationSpan":{"start":[4,0],"end":[4,11]},"span":[30,40]}]}],"lineEndings":"LF"}}
+{"jsonrpc":"2.0","id":8,"result":{"type":"file","name":"b.go","locationSpan":{"start":[1,0],"end":[5,2]},"footerSpan":[0,-1],"parsingErrorsDetected":false,"children":[{"type":"Package","name":"main","locationSpan":{"start":[1,0],"end":[1,13]},"span":[0,12]},{"type":"Struct","name":"T","exported":true,"locationSpan":{"start":[2,0],"end":[5,2]},"headerSpan":[13,29],"footerSpan":[41,42],"children":[{"type":"Field","name":"io.Reader","exported":true,"locationSpan":{"start":[4,0],"end":[4,11]},"span":[30,40]}]}],"lineEndings":"LF"}}
+`
+	assert.Equal(t, expectedOutput, string(output))
+}
+
+func TestSmgoCliIndex(t *testing.T) {
+	cli := filepath.Join(os.Getenv("GOPATH"), "bin", "smgo-cli")
+	if runtime.GOOS == "windows" {
+		cli = cli + ".exe"
+	}
+	dir, err := ioutil.TempDir("", "smgo-index")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "person.go")
+	err = ioutil.WriteFile(src, []byte("package person\n\ntype Person struct {\n\tName string\n}\n\n"+
+		"func (p *Person) SayHi() {}\n\nfunc SayHi() {}\n"), 0644)
+	require.Nil(t, err)
+	output := filepath.Join(dir, "index.json")
+
+	out, err := exec.Command(cli, "index", "-o", output, "testdata", dir).Output()
+	require.Nil(t, err)
+	assert.Equal(t, "2 files indexed, 2 parsed"+newLine, string(out))
+	index, err := ioutil.ReadFile(output)
+	require.Nil(t, err)
+	var idx struct {
+		Symbols map[string][]struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		} `json:"symbols"`
+	}
+	err = json.Unmarshal(index, &idx)
+	require.Nil(t, err)
+	require.Len(t, idx.Symbols["Person.Name"], 1)
+	assert.Equal(t, filepath.ToSlash(src), idx.Symbols["Person.Name"][0].Path)
+	assert.Equal(t, "Field", idx.Symbols["Person.Name"][0].Type)
+	require.Len(t, idx.Symbols["Hi"], 1)
+	// methods are qualified with their receivers
+	require.Len(t, idx.Symbols["Person.SayHi"], 1)
+	assert.Equal(t, "Function", idx.Symbols["Person.SayHi"][0].Type)
+	require.Len(t, idx.Symbols["SayHi"], 1)
+
+	// unchanged files aren't parsed again
+	err = ioutil.WriteFile(src, []byte("package person\n\ntype Person struct {\n\tName string\n\tAge  int\n}\n"), 0644)
+	require.Nil(t, err)
+	out, err = exec.Command(cli, "index", "testdata", dir, "-o", output).Output()
+	require.Nil(t, err)
+	assert.Equal(t, "2 files indexed, 1 parsed"+newLine, string(out))
+	index, err = ioutil.ReadFile(output)
+	require.Nil(t, err)
+	err = json.Unmarshal(index, &idx)
+	require.Nil(t, err)
+	assert.Len(t, idx.Symbols["Person.Age"], 1)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+)
+
+// Manifest maps every file to its declarations. It's deterministic: files are sorted by
+// path and hashes only depend on the contents of the files. Build is the build context
+// selecting the files, if any.
+type Manifest struct {
+	Build *ManifestBuild  `json:"build,omitempty"`
+	Files []*ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file and its declarations. Constraint is its build constraint, see
+// buildConstraint.
+type ManifestFile struct {
+	Path                  string                 `json:"path"`
+	Hash                  string                 `json:"hash"`
+	Constraint            string                 `json:"constraint,omitempty"`
+	ParsingErrorsDetected bool                   `json:"parsingErrorsDetected"`
+	Declarations          []*ManifestDeclaration `json:"declarations,omitempty"`
+}
+
+// ManifestDeclaration is a declaration of a file. Hash is the hash of its source code,
+// including its doc comment; for containers it covers the children too.
+type ManifestDeclaration struct {
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	ID       string                 `json:"id"`
+	Hash     string                 `json:"hash"`
+	Children []*ManifestDeclaration `json:"children,omitempty"`
+}
+
+// manifest runs "smgo-cli manifest [-o output] <pattern>...", writing the manifest of the UTF-8
+// GO files matched by the patterns to the output file, or to w if no output is given. A
+// pattern is a file, a directory, or a directory followed by "/..." to include its
+// subdirectories, skipping testdata and the directories starting with "." or "_". With
+// -goos, -goarch or -tags, only the files of the directories satisfying the build context are
+// included.
+func manifest(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("manifest", flag.ContinueOnError)
+	output := fs.String("o", "", "output file (stdout by default)")
+	paths, ctx, err := parsePatterns(fs, args)
+	if err != nil {
+		return err
+	}
+	m := &Manifest{
+		Build: manifestBuild(ctx),
+		Files: make([]*ManifestFile, 0, len(paths)),
+	}
+	for _, path := range paths {
+		file, err := manifestFile(path)
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, file)
+	}
+	return writeJSON(m, *output, w)
+}
+
+// parsePatterns parses the flags of fs, plus the build flags, and the patterns in args, which
+// may be mixed, and returns the GO files matched by the patterns, but the ones skipped by
+// their profiles, and the build context selecting them, if any.
+func parsePatterns(fs *flag.FlagSet, args []string) ([]string, *build.Context, error) {
+	bf := addBuildFlags(fs)
+	var patterns []string
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		patterns = append(patterns, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(patterns) == 0 {
+		return nil, nil, errors.New("no patterns given")
+	}
+	ctx := bf.context()
+	matches, err := matchGoFiles(patterns, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths := make([]string, 0, len(matches))
+	for _, path := range matches {
+		rule, err := profileRule(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !rule.skipped(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, ctx, nil
+}
+
+// writeJSON writes v as indented JSON to the output file, or to w if output is empty.
+func writeJSON(v interface{}, output string, w io.Writer) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error encoding JSON")
+	}
+	content = append(content, '\n')
+	if output == "" {
+		_, err = w.Write(content)
+		return err
+	}
+	return ioutil.WriteFile(output, content, 0644)
+}
+
+// matchGoFiles returns the sorted paths of the GO files matched by patterns. If ctx isn't nil,
+// the files of directories not satisfying it are skipped; files given explicitly are always
+// included.
+func matchGoFiles(patterns []string, ctx *build.Context) ([]string, error) {
+	matches := make(map[string]bool)
+	for _, pattern := range patterns {
+		recursive := pattern == "..." || strings.HasSuffix(pattern, "/...")
+		root := filepath.Clean(strings.TrimSuffix(pattern, "..."))
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			matches[filepath.ToSlash(root)] = true
+			continue
+		}
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path == root {
+					return nil
+				}
+				name := info.Name()
+				if !recursive || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(path) != ".go" {
+				return nil
+			}
+			if ctx != nil {
+				ok, err := ctx.MatchFile(filepath.Dir(path), info.Name())
+				if err != nil || !ok {
+					return err
+				}
+			}
+			matches[filepath.ToSlash(path)] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	paths := make([]string, 0, len(matches))
+	for path := range matches {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func manifestFile(path string) (*ManifestFile, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := parseOptions(path)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, smgo.WithStableIDs())
+	dtFile, err := smgo.Parse(bytes.NewReader(src), "UTF-8", opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", path)
+	}
+	return &ManifestFile{
+		Path:                  path,
+		Hash:                  hash(src),
+		Constraint:            buildConstraint(src),
+		ParsingErrorsDetected: len(dtFile.ParsingErrors) > 0,
+		Declarations:          manifestDeclarations(dtFile.Children, src),
+	}, nil
+}
+
+// manifestDeclarations returns the declarations in nodes, skipping comments.
+func manifestDeclarations(nodes []smgo.Node, src []byte) []*ManifestDeclaration {
+	var decls []*ManifestDeclaration
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *smgo.Terminal:
+			if n.Type == smgo.Comment {
+				continue
+			}
+			decls = append(decls, &ManifestDeclaration{
+				Type: toType(n),
+				Name: n.Name,
+				ID:   n.ID,
+				Hash: hash(spanText(src, n.Span.Start, n.Span.End)),
+			})
+		case *smgo.Container:
+			decls = append(decls, &ManifestDeclaration{
+				Type:     toType(n),
+				Name:     n.Name,
+				ID:       n.ID,
+				Hash:     hash(spanText(src, n.HeaderSpan.Start, n.FooterSpan.End)),
+				Children: manifestDeclarations(n.Children, src),
+			})
+		}
+	}
+	return decls
+}
+
+// spanText returns the text of the inclusive span [start, end] of src, without the
+// surrounding whitespace.
+func spanText(src []byte, start, end int) []byte {
+	if start < 0 || end < start || start >= len(src) {
+		return nil
+	}
+	if end >= len(src) {
+		end = len(src) - 1
+	}
+	return bytes.TrimSpace(src[start : end+1])
+}
+
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// profileName is the name of the parsing profile files.
+const profileName = ".smgo.yaml"
+
+// Profile is a parsing profile, checked in a repository to tune the parsing of its files, for
+// instance of generated and hand-written ones. The profile of a file is the nearest
+// .smgo.yaml in its directory or its parent directories, and the first rule matching the file
+// applies to it:
+//
+//	rules:
+//	- path: gen/...
+//	  tests: false
+//	  invalidUTF8: passthrough
+//	- path: "*.pb.go"
+//	  skip: true
+type Profile struct {
+	Rules []*ProfileRule `yaml:"rules"`
+
+	dir string
+}
+
+// ProfileRule is a rule of a Profile. Path is a pattern matched against the slash-separated
+// path of the file relative to the directory of the profile: "dir/..." matches the files in
+// dir and its subdirectories, "..." every file, and other patterns are path.Match patterns,
+// matched against the base name of the file if they have no "/". The settings given override
+// the flags of the same name.
+type ProfileRule struct {
+	Path         string  `yaml:"path"`
+	IDs          *bool   `yaml:"ids"`
+	Lossy        *bool   `yaml:"lossy"`
+	InvalidUTF8  *string `yaml:"invalidUTF8"`
+	GroupMethods *bool   `yaml:"groupMethods"`
+	// Tests is false to skip the test files in manifest, sarif and index.
+	Tests *bool `yaml:"tests"`
+	// Skip skips the files in manifest, sarif and index.
+	Skip bool `yaml:"skip"`
+}
+
+var (
+	profilesMu sync.Mutex
+	// profiles caches the profiles by directory, with the modification time and size of their
+	// files: a profile is read again when its file changes, as the shell outlives the edits
+	// of the profiles
+	profiles = make(map[string]*cachedProfile)
+)
+
+type cachedProfile struct {
+	profile *Profile
+	modTime time.Time
+	size    int64
+}
+
+// profileRule returns the rule of the profile of the file in path applying to it, or nil if
+// there's none.
+func profileRule(path string) (*ProfileRule, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := findProfile(filepath.Dir(abs))
+	if err != nil || profile == nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(profile.dir, abs)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+	for _, rule := range profile.Rules {
+		if matchProfilePath(rule.Path, rel) {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// findProfile returns the profile of the directory dir, or nil if neither dir nor its parent
+// directories have one.
+func findProfile(dir string) (*Profile, error) {
+	for {
+		profile, err := cachedReadProfile(dir)
+		if err != nil || profile != nil {
+			return profile, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// cachedReadProfile is readProfile, reading the profile again only if its file changed since
+// it was cached.
+func cachedReadProfile(dir string) (*Profile, error) {
+	info, err := os.Stat(filepath.Join(dir, profileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading profile")
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	if c, ok := profiles[dir]; ok && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.profile, nil
+	}
+	profile, err := readProfile(dir)
+	if err != nil {
+		return nil, err
+	}
+	profiles[dir] = &cachedProfile{profile, info.ModTime(), info.Size()}
+	return profile, nil
+}
+
+// readProfile reads the profile in dir, returning nil if there's none.
+func readProfile(dir string) (*Profile, error) {
+	profilePath := filepath.Join(dir, profileName)
+	content, err := ioutil.ReadFile(profilePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading profile")
+	}
+	profile := &Profile{dir: dir}
+	err = yaml.UnmarshalStrict(content, profile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid profile %s", profilePath)
+	}
+	for _, rule := range profile.Rules {
+		if rule.InvalidUTF8 != nil {
+			if _, ok := invalidUTF8Policies[*rule.InvalidUTF8]; !ok {
+				return nil, errors.Errorf("invalid invalidUTF8 value %s in %s", *rule.InvalidUTF8, profilePath)
+			}
+		}
+	}
+	return profile, nil
+}
+
+func matchProfilePath(pattern, rel string) bool {
+	switch {
+	case pattern == "...":
+		return true
+	case strings.HasSuffix(pattern, "/..."):
+		dir := strings.TrimSuffix(pattern, "/...")
+		return strings.HasPrefix(rel, dir+"/")
+	case !strings.Contains(pattern, "/"):
+		rel = path.Base(rel)
+	}
+	matched, _ := path.Match(pattern, rel)
+	return matched
+}
+
+// skipped reports whether the file in path is skipped by its profile rule.
+func (r *ProfileRule) skipped(path string) bool {
+	if r == nil {
+		return false
+	}
+	return r.Skip || r.Tests != nil && !*r.Tests && strings.HasSuffix(path, "_test.go")
+}
+
+// options returns the parse options of the flags, overridden by r.
+func (r *ProfileRule) options() []smgo.Option {
+	stableIDs, lossyDecoding, policy, grouping := *ids, *lossy, *invalidUTF8, *methods
+	if r != nil {
+		if r.IDs != nil {
+			stableIDs = *r.IDs
+		}
+		if r.Lossy != nil {
+			lossyDecoding = *r.Lossy
+		}
+		if r.InvalidUTF8 != nil {
+			policy = *r.InvalidUTF8
+		}
+		if r.GroupMethods != nil {
+			grouping = *r.GroupMethods
+		}
+	}
+	opts := []smgo.Option{smgo.WithInvalidUTF8Policy(invalidUTF8Policy(policy))}
+	if stableIDs {
+		opts = append(opts, smgo.WithStableIDs())
+	}
+	if lossyDecoding {
+		opts = append(opts, smgo.WithLossyDecoding(utf8.RuneError))
+	}
+	if *timings {
+		opts = append(opts, smgo.WithStats())
+	}
+	if *strict {
+		opts = append(opts, smgo.WithStrictMode())
+	}
+	if *encoded {
+		opts = append(opts, smgo.WithEncodedOffsets())
+	}
+	if grouping {
+		opts = append(opts, smgo.WithMethodGrouping())
+	}
+	return opts
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIF rules.
+const (
+	parsingErrorRule  = "parsing-error"
+	spanInvariantRule = "span-invariant"
+	warningRule       = "warning"
+)
+
+// SarifLog is a SARIF 2.1.0 log, with the subset of properties used by smgo-cli.
+type SarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool    SarifTool      `json:"tool"`
+	Results []*SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription SarifMessage `json:"shortDescription"`
+}
+
+type SarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	Level     string           `json:"level"`
+	Message   SarifMessage     `json:"message"`
+	Locations []*SarifLocation `json:"locations"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           SarifRegion           `json:"region"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarif runs "smgo-cli sarif [-o output] <pattern>...", writing the parsing errors, span
+// invariant violations (see smgo.CheckSpans) and warnings of the UTF-8 GO files matched by
+// the patterns as a SARIF log to the output file, or to w if no output is given. The
+// patterns are the same of manifest.
+func sarif(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("sarif", flag.ContinueOnError)
+	output := fs.String("o", "", "output file (stdout by default)")
+	paths, _, err := parsePatterns(fs, args)
+	if err != nil {
+		return err
+	}
+	run := &SarifRun{
+		Tool: SarifTool{
+			Driver: SarifDriver{
+				Name:           "smgo",
+				InformationURI: "https://github.com/jriquelme/SemanticMergeGO",
+				Rules: []*SarifRule{
+					{ID: parsingErrorRule, ShortDescription: SarifMessage{"The file can't be parsed"}},
+					{ID: spanInvariantRule, ShortDescription: SarifMessage{"The declarations tree violates a span invariant"}},
+					{ID: warningRule, ShortDescription: SarifMessage{"The file was parsed with a warning"}},
+				},
+			},
+		},
+		Results: []*SarifResult{},
+	}
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		opts, err := parseOptions(path)
+		if err != nil {
+			return err
+		}
+		dtFile, err := smgo.Parse(bytes.NewReader(src), "UTF-8", opts...)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing %s", path)
+		}
+		// parsing errors have 1-based columns, warnings 0-based columns
+		for _, parsingError := range dtFile.ParsingErrors {
+			run.Results = append(run.Results, sarifResult(path, parsingErrorRule, "error",
+				parsingError.Message, parsingError.Location.Line, parsingError.Location.Column))
+		}
+		for _, violation := range smgo.CheckSpans(dtFile, len(src)) {
+			run.Results = append(run.Results, sarifResult(path, spanInvariantRule, "error",
+				violation.Message, violation.Location.Line, violation.Location.Column+1))
+		}
+		for _, warning := range dtFile.Warnings {
+			run.Results = append(run.Results, sarifResult(path, warningRule, "warning",
+				warning.Message, warning.Location.Line, warning.Location.Column+1))
+		}
+	}
+	sarifLog := &SarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []*SarifRun{run},
+	}
+	return writeJSON(sarifLog, *output, w)
+}
+
+// sarifResult returns a result in the 1-based line and column of path (SARIF doesn't allow
+// smaller values).
+func sarifResult(path, ruleID, level, message string, line, column int) *SarifResult {
+	if line < 1 {
+		line = 1
+	}
+	if column < 1 {
+		column = 1
+	}
+	return &SarifResult{
+		RuleID:  ruleID,
+		Level:   level,
+		Message: SarifMessage{message},
+		Locations: []*SarifLocation{
+			{
+				PhysicalLocation: SarifPhysicalLocation{
+					ArtifactLocation: SarifArtifactLocation{URI: path},
+					Region: SarifRegion{
+						StartLine:   line,
+						StartColumn: column,
+					},
+				},
+			},
+		},
+	}
+}
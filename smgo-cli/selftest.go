@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const selftestSrc = `package selftest
+
+import "fmt"
+
+// Person is a person.
+type Person struct {
+	Name string
+}
+
+func (p *Person) SayHi() {
+	fmt.Println("Hi, I'm " + p.Name)
+}
+`
+
+type selftestRequest struct {
+	Name             string
+	Source           string
+	Encoding         string
+	Output           string
+	ExpectedResponse string
+}
+
+// selftest plays the SemanticMerge role against "smgo-cli shell": it sends valid and invalid
+// requests, checks the OK/KO responses and validates the produced YAML files. Progress is
+// reported to w.
+func selftest(w io.Writer) error {
+	cli, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "error locating smgo-cli")
+	}
+	dir, err := ioutil.TempDir("", "smgo-selftest")
+	if err != nil {
+		return errors.Wrap(err, "error creating temp dir")
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "selftest.go")
+	err = ioutil.WriteFile(src, []byte(selftestSrc), 0644)
+	if err != nil {
+		return errors.Wrap(err, "error writing source file")
+	}
+	requests := []selftestRequest{
+		{
+			Name:             "valid file",
+			Source:           src,
+			Encoding:         "UTF-8",
+			Output:           filepath.Join(dir, "valid.yaml"),
+			ExpectedResponse: "OK",
+		},
+		{
+			Name:             "missing file",
+			Source:           filepath.Join(dir, "missing.go"),
+			Encoding:         "UTF-8",
+			Output:           filepath.Join(dir, "missing.yaml"),
+			ExpectedResponse: "KO",
+		},
+		{
+			Name:             "unsupported encoding",
+			Source:           src,
+			Encoding:         "EBCDIC",
+			Output:           filepath.Join(dir, "encoding.yaml"),
+			ExpectedResponse: "KO",
+		},
+		{
+			Name:             "output in missing dir",
+			Source:           src,
+			Encoding:         "UTF-8",
+			Output:           filepath.Join(dir, "missing", "output.yaml"),
+			ExpectedResponse: "KO",
+		},
+	}
+
+	flagFile := filepath.Join(dir, "flag-file")
+	cmd := exec.Command(cli, "shell", flagFile)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return errors.Wrap(err, "error creating stdin pipe")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "error creating stdout pipe")
+	}
+	err = cmd.Start()
+	if err != nil {
+		return errors.Wrap(err, "error starting smgo-cli shell")
+	}
+	defer cmd.Process.Kill()
+
+	scanner := bufio.NewScanner(stdout)
+	for _, req := range requests {
+		fmt.Fprintf(stdin, "%s\n%s\n%s\n", req.Source, req.Encoding, req.Output)
+		if !scanner.Scan() {
+			return errors.Errorf("%s: no response: %v", req.Name, scanner.Err())
+		}
+		if scanner.Text() != req.ExpectedResponse {
+			return errors.Errorf("%s: expected %s, got %q", req.Name, req.ExpectedResponse, scanner.Text())
+		}
+		fmt.Fprintf(w, "%s: %s\n", req.Name, scanner.Text())
+	}
+	fmt.Fprintln(stdin, "end")
+	err = cmd.Wait()
+	if err != nil {
+		return errors.Wrap(err, "smgo-cli shell didn't exit cleanly")
+	}
+	fmt.Fprintln(w, "shutdown: OK")
+
+	flag, err := ioutil.ReadFile(flagFile)
+	if err != nil {
+		return errors.Wrap(err, "flag file not written")
+	}
+	if len(flag) == 0 {
+		return errors.New("flag file is empty")
+	}
+	fmt.Fprintln(w, "flag file: OK")
+
+	err = checkSelftestOutput(src, requests[0].Output)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "output: OK")
+	return nil
+}
+
+// checkSelftestOutput validates the YAML file produced by the shell for src.
+func checkSelftestOutput(src, output string) error {
+	outputBytes, err := ioutil.ReadFile(output)
+	if err != nil {
+		return errors.Wrap(err, "error reading output")
+	}
+	var file struct {
+		Type                  string `yaml:"type"`
+		Name                  string `yaml:"name"`
+		ParsingErrorsDetected bool   `yaml:"parsingErrorsDetected"`
+		Children              []struct {
+			Type string `yaml:"type"`
+			Name string `yaml:"name"`
+		} `yaml:"children"`
+	}
+	err = yaml.Unmarshal(outputBytes, &file)
+	if err != nil {
+		return errors.Wrap(err, "invalid output")
+	}
+	if file.Type != "file" || file.Name != src || file.ParsingErrorsDetected {
+		return errors.Errorf("unexpected file header in output: %s %s %t", file.Type, file.Name,
+			file.ParsingErrorsDetected)
+	}
+	if len(file.Children) != 4 || file.Children[0].Type != "Package" || file.Children[2].Name != "Person" {
+		return errors.Errorf("unexpected children in output: %v", file.Children)
+	}
+
+	// the output must match the tree built in-process
+	dtFile, err := smgo.Parse(bytes.NewReader([]byte(selftestSrc)), "UTF-8")
+	if err != nil {
+		return errors.Wrap(err, "error parsing source")
+	}
+	var expected bytes.Buffer
+	// the shell is started without flags
+	err = smgo.Write(&expected, src, dtFile)
+	if err != nil {
+		return errors.Wrap(err, "error encoding expected output")
+	}
+	if !bytes.Equal(outputBytes, expected.Bytes()) {
+		return errors.New("output doesn't match the expected declarations tree")
+	}
+	return nil
+}
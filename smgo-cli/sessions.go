@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+)
+
+// rpcSession is a file opened with the "open" method: its UTF-8 source code and declarations
+// tree, updated by the "edit" method.
+type rpcSession struct {
+	Src  []byte
+	File *smgo.File
+	Opts []smgo.Option
+}
+
+// rpcSessions are the open sessions, by id.
+type rpcSessions map[string]*rpcSession
+
+// openParams are the params of the "open" method: the UTF-8 source code is read from Path,
+// or taken from Source when Path is empty. ID identifies the session in later requests.
+type openParams struct {
+	ID         string `json:"id"`
+	Path       string `json:"path"`
+	Source     string `json:"source"`
+	IDs        bool   `json:"ids"`
+	Complexity bool   `json:"complexity"`
+	Metrics    bool   `json:"metrics"`
+}
+
+type editParams struct {
+	ID    string     `json:"id"`
+	Edits []textEdit `json:"edits"`
+}
+
+// textEdit replaces the bytes [Start, End) of the source code with NewText.
+type textEdit struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	NewText string `json:"newText"`
+}
+
+type sessionParams struct {
+	ID string `json:"id"`
+}
+
+// Open parses the file of a new session, returning its declarations tree.
+func (s rpcSessions) Open(rawParams json.RawMessage) (interface{}, *rpcError) {
+	var params openParams
+	err := json.Unmarshal(rawParams, &params)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	if params.ID == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "missing id"}
+	}
+	src := []byte(params.Source)
+	if params.Path != "" {
+		src, err = ioutil.ReadFile(params.Path)
+		if err != nil {
+			return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+		}
+	}
+	var opts []smgo.Option
+	if params.IDs {
+		opts = append(opts, smgo.WithStableIDs())
+	}
+	if params.Complexity {
+		opts = append(opts, smgo.WithComplexity())
+	}
+	if params.Metrics {
+		opts = append(opts, smgo.WithMetrics())
+	}
+	dtFile, err := smgo.Parse(bytes.NewReader(src), "UTF-8", opts...)
+	if err != nil {
+		return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+	}
+	session := &rpcSession{
+		Src:  src,
+		File: dtFile,
+		Opts: opts,
+	}
+	s[params.ID] = session
+	return session.Tree(params.ID), nil
+}
+
+// Edit applies edits to the file of a session, returning its updated declarations tree.
+func (s rpcSessions) Edit(rawParams json.RawMessage) (interface{}, *rpcError) {
+	var params editParams
+	err := json.Unmarshal(rawParams, &params)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	session, rpcErr := s.session(params.ID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			// the tree could be half updated: the session is closed
+			delete(s, params.ID)
+			panic(r)
+		}
+	}()
+	edits := make([]smgo.TextEdit, 0, len(params.Edits))
+	for _, edit := range params.Edits {
+		edits = append(edits, smgo.TextEdit{
+			Start:   edit.Start,
+			End:     edit.End,
+			NewText: edit.NewText,
+		})
+	}
+	src, err := session.File.ApplyEdits(session.Src, edits, session.Opts...)
+	if err == smgo.ErrInvalidEdits {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+	}
+	session.Src = src
+	return session.Tree(params.ID), nil
+}
+
+// Tree returns the declarations tree of the file of a session.
+func (s rpcSessions) Tree(rawParams json.RawMessage) (interface{}, *rpcError) {
+	var params sessionParams
+	err := json.Unmarshal(rawParams, &params)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	session, rpcErr := s.session(params.ID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return session.Tree(params.ID), nil
+}
+
+// Close closes a session.
+func (s rpcSessions) Close(rawParams json.RawMessage) (interface{}, *rpcError) {
+	var params sessionParams
+	err := json.Unmarshal(rawParams, &params)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	_, rpcErr := s.session(params.ID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	delete(s, params.ID)
+	return true, nil
+}
+
+func (s rpcSessions) session(id string) (*rpcSession, *rpcError) {
+	session, ok := s[id]
+	if !ok {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "unknown session: " + id}
+	}
+	return session, nil
+}
+
+// Tree returns the declarations tree of the session, named id.
+func (session *rpcSession) Tree(id string) *File {
+	file := toFile(session.File)
+	file.Name = id
+	return file
+}
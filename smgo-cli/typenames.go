@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// typeNames maps the default types of the nodes (e.g. "Struct") to the types written instead,
+// loaded from the file given with -types.
+var typeNames map[string]string
+
+// loadTypeNames reads the YAML mapping of default types to types in path, like:
+//
+//	Struct: class
+//	Function: method
+//
+// so the output can use the vocabulary of other SemanticMerge parsers.
+func loadTypeNames(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names map[string]string
+	err = yaml.UnmarshalStrict(content, &names)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding %s", path)
+	}
+	known := make(map[string]bool)
+	for t := smgo.PackageNode; t <= smgo.Comment; t++ {
+		known[smgo.TypeName(t)] = true
+	}
+	known[smgo.AliasType] = true
+	for name, mapped := range names {
+		if !known[name] {
+			return nil, errors.Errorf("unknown type %s in %s", name, path)
+		}
+		if mapped == "" {
+			return nil, errors.Errorf("empty type for %s in %s", name, path)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,153 @@
+package smgo_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestdata(t testing.TB) [][]byte {
+	paths, err := filepath.Glob("testdata/*.go")
+	require.Nil(t, err)
+	var srcs [][]byte
+	for _, path := range paths {
+		if filepath.Base(path) == "encoding_cyrillic.go" {
+			continue
+		}
+		src, err := ioutil.ReadFile(path)
+		require.Nil(t, err)
+		srcs = append(srcs, src)
+	}
+	return srcs
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	batch := smgo.NewBatch(smgo.WithStableIDs())
+	for _, src := range readTestdata(t) {
+		expected, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithStableIDs())
+		require.Nil(t, err)
+		file, err := batch.Parse(bytes.NewReader(src), "UTF-8")
+		require.Nil(t, err)
+		assert.Equal(t, expected, file)
+	}
+	for _, src := range []string{"", "package", "package batch\n\nfunc A( {\n}\n"} {
+		expected, err := smgo.Parse(bytes.NewReader([]byte(src)), "UTF-8")
+		require.Nil(t, err)
+		file, err := batch.Parse(bytes.NewReader([]byte(src)), "UTF-8")
+		require.Nil(t, err)
+		assert.Equal(t, expected, file)
+	}
+}
+
+func TestBatchParseMany(t *testing.T) {
+	t.Parallel()
+
+	srcs := readTestdata(t)
+	srcs = append(srcs, []byte("package batch\n\nfunc A( {\n}\n"))
+	var expected []*smgo.File
+	for _, src := range srcs {
+		file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithStableIDs())
+		require.Nil(t, err)
+		expected = append(expected, file)
+	}
+
+	batch := smgo.NewBatch(smgo.WithStableIDs())
+	for _, workers := range []int{0, 1, 4} {
+		files := make([]*smgo.File, len(srcs))
+		for result := range batch.ParseMany(srcs, "UTF-8", workers) {
+			require.Nil(t, result.Err)
+			require.Nil(t, files[result.Index], "result sent twice")
+			files[result.Index] = result.File
+		}
+		assert.Equal(t, expected, files, "%d workers", workers)
+	}
+
+	for result := range batch.ParseMany(srcs[:2], "Shift_JIS", 2) {
+		assert.Equal(t, smgo.ErrUnsupportedEncoding, result.Err)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	srcs := readTestdata(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, src := range srcs {
+			_, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkBatchParse(b *testing.B) {
+	srcs := readTestdata(b)
+	batch := smgo.NewBatch()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, src := range srcs {
+			_, err := batch.Parse(bytes.NewReader(src), "UTF-8")
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkRetained reports the memory retained by the declarations trees of 100 copies of
+// the test files, parsed one by one or in a batch.
+func BenchmarkRetained(b *testing.B) {
+	srcs := readTestdata(b)
+	parsers := []struct {
+		Name  string
+		Parse func() func(src []byte) (*smgo.File, error)
+	}{
+		{"Parse", func() func(src []byte) (*smgo.File, error) {
+			return func(src []byte) (*smgo.File, error) {
+				return smgo.Parse(bytes.NewReader(src), "UTF-8")
+			}
+		}},
+		{"Batch", func() func(src []byte) (*smgo.File, error) {
+			batch := smgo.NewBatch()
+			return func(src []byte) (*smgo.File, error) {
+				return batch.Parse(bytes.NewReader(src), "UTF-8")
+			}
+		}},
+	}
+	for _, p := range parsers {
+		p := p
+		b.Run(p.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				parse := p.Parse()
+				files := make([]*smgo.File, 0, 100*len(srcs))
+				for j := 0; j < 100; j++ {
+					for _, src := range srcs {
+						file, err := parse(src)
+						if err != nil {
+							b.Fatal(err)
+						}
+						files = append(files, file)
+					}
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc), "retained-B")
+				runtime.KeepAlive(files)
+				runtime.KeepAlive(parse)
+			}
+		})
+	}
+}
@@ -1,11 +1,12 @@
 package smgo
 
 import (
+	"fmt"
 	"go/token"
-
-	"github.com/davecgh/go-spew/spew"
 )
 
+// PrintBlocks prints the blocks of the parsed files to stdout, before and after fixing their
+// boundaries, for debugging.
 var PrintBlocks bool
 
 type blockType int
@@ -53,7 +54,9 @@ func addBlocksFrom(parent parentNode, blocks *[]block) {
 	}
 }
 
-func fixBlockBoundaries(fileSet *token.FileSet, file *File, src []byte) error {
+// fixBlockBoundaries makes the spans of the blocks of file partition src. base is the base of
+// the file of src in fileSet.
+func fixBlockBoundaries(fileSet *token.FileSet, base int, file *File, src []byte) error {
 	var blocks []block
 	addBlocksFrom(file, &blocks)
 
@@ -70,14 +73,14 @@ func fixBlockBoundaries(fileSet *token.FileSet, file *File, src []byte) error {
 		case nodeBlock:
 			n := b.Terminal()
 			n.Span.Start = offset
-			newPos := fileSet.Position(token.Pos(n.Span.Start + 1))
+			newPos := fileSet.Position(token.Pos(base + n.Span.Start))
 			n.LocationSpan.Start.Line = newPos.Line
 			n.LocationSpan.Start.Column = newPos.Column - 1
 			offset = n.Span.End + 1
 		case containerHeader:
 			n := b.Container()
 			n.HeaderSpan.Start = offset
-			newPos := fileSet.Position(token.Pos(n.HeaderSpan.Start + 1))
+			newPos := fileSet.Position(token.Pos(base + n.HeaderSpan.Start))
 			n.LocationSpan.Start.Line = newPos.Line
 			n.LocationSpan.Start.Column = newPos.Column - 1
 			if (src[n.HeaderSpan.End] == '(' || src[n.HeaderSpan.End] == '{') && src[n.HeaderSpan.End+1] == '\n' {
@@ -90,7 +93,7 @@ func fixBlockBoundaries(fileSet *token.FileSet, file *File, src []byte) error {
 			if (src[n.FooterSpan.End] == ')' || src[n.FooterSpan.End] == '}') && src[n.FooterSpan.End+1] == '\n' {
 				n.FooterSpan.End++
 			}
-			newPos := fileSet.Position(token.Pos(n.FooterSpan.End + 1))
+			newPos := fileSet.Position(token.Pos(base + n.FooterSpan.End))
 			n.LocationSpan.End.Line = newPos.Line
 			n.LocationSpan.End.Column = newPos.Column
 			offset = n.FooterSpan.End + 1
@@ -110,43 +113,23 @@ func fixBlockBoundaries(fileSet *token.FileSet, file *File, src []byte) error {
 	return nil
 }
 
-type debugBlock struct {
-	BlockType    blockType
-	Name         string
-	LocationSpan LocationSpan
-	Span         RuneSpan
-}
-
+// printBlocks prints blocks to stdout, in the format of Dump.
 func printBlocks(title string, blocks []block) {
-	debugBlocks := make([]debugBlock, 0, len(blocks))
+	fmt.Printf("----------%s----------\n", title)
 	for _, b := range blocks {
 		switch b.Type {
 		case nodeBlock:
-			debugBlocks = append(debugBlocks, debugBlock{
-				BlockType:    nodeBlock,
-				Name:         b.Terminal().Name,
-				LocationSpan: b.Terminal().LocationSpan,
-				Span:         b.Terminal().Span,
-			})
+			n := b.Terminal()
+			fmt.Printf("%s %q %s span %s\n", b.Type, n.Name, n.LocationSpan, n.Span)
 		case containerHeader:
-			debugBlocks = append(debugBlocks, debugBlock{
-				BlockType:    containerHeader,
-				Name:         b.Container().Name,
-				LocationSpan: b.Container().LocationSpan,
-				Span:         b.Container().HeaderSpan,
-			})
+			n := b.Container()
+			fmt.Printf("%s %q %s span %s\n", b.Type, n.Name, n.LocationSpan, n.HeaderSpan)
 		case containerFooter:
-			debugBlocks = append(debugBlocks, debugBlock{
-				BlockType:    containerFooter,
-				Name:         b.Container().Name,
-				LocationSpan: b.Container().LocationSpan,
-				Span:         b.Container().FooterSpan,
-			})
+			n := b.Container()
+			fmt.Printf("%s %q %s span %s\n", b.Type, n.Name, n.LocationSpan, n.FooterSpan)
 		default:
 			panic("impossibru!")
 		}
 	}
-	spew.Printf("----------%s----------\n", title)
-	spew.Dump(debugBlocks)
-	spew.Printf("--------------------\n")
+	fmt.Printf("--------------------\n")
 }
@@ -0,0 +1,181 @@
+package smgo
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrDeclNotFound  = errors.New("Declaration not found")
+	ErrAmbiguousDecl = errors.New("More than one declaration matches")
+	ErrSrcHasErrors  = errors.New("Source code has parsing errors")
+	ErrUnknownChange = errors.New("Unknown change kind")
+)
+
+// ChangeKind is the operation performed by a Change.
+type ChangeKind int
+
+const (
+	AddDecl ChangeKind = iota
+	RemoveDecl
+	ReplaceDecl
+	MoveDecl
+)
+
+// Change is a declaration-level edit. Type, Name and Receiver (the receiver type of methods,
+// e.g. "T" for "func (t *T) String()", empty otherwise) identify the top-level declaration
+// removed, replaced or moved. Src is the source code of the added or replacing
+// declaration, including its doc comment. After is the name of the top-level declaration
+// after which added and moved declarations are placed; an empty After means the end of the
+// file.
+type Change struct {
+	Kind     ChangeKind
+	Type     NodeType
+	Name     string
+	Receiver string
+	Src      string
+	After    string
+}
+
+// ChangeSet is a list of changes, applied in order.
+type ChangeSet []Change
+
+// Apply applies the declaration-level changes in cs to the UTF-8 encoded GO source code in
+// src, returning the modified source code. src is not modified.
+func Apply(src []byte, cs ChangeSet) ([]byte, error) {
+	for i, c := range cs {
+		var err error
+		src, err = applyChange(src, c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error applying change %d (%s)", i, c.Name)
+		}
+	}
+	return src, nil
+}
+
+func applyChange(src []byte, c Change) ([]byte, error) {
+	file, err := Parse(bytes.NewReader(src), "UTF-8")
+	if err != nil {
+		return nil, err
+	}
+	if len(file.ParsingErrors) > 0 {
+		return nil, ErrSrcHasErrors
+	}
+	switch c.Kind {
+	case AddDecl:
+		at, err := insertionOffset(file, src, c.After)
+		if err != nil {
+			return nil, err
+		}
+		text := "\n" + c.Src + "\n"
+		if at > 0 && src[at-1] != '\n' {
+			text = "\n" + text
+		}
+		return splice(src, at, at, []byte(text)), nil
+	case RemoveDecl:
+		start, end, err := declRange(file, src, c)
+		if err != nil {
+			return nil, err
+		}
+		return splice(src, start, end, nil), nil
+	case ReplaceDecl:
+		start, end, err := declRange(file, src, c)
+		if err != nil {
+			return nil, err
+		}
+		// preserve the whitespace around the declaration
+		declStart := start
+		for declStart < end && isSpace(src[declStart]) {
+			declStart++
+		}
+		declEnd := end
+		if declEnd > declStart && src[declEnd-1] == '\n' {
+			declEnd--
+		}
+		return splice(src, declStart, declEnd, []byte(c.Src)), nil
+	case MoveDecl:
+		start, end, err := declRange(file, src, c)
+		if err != nil {
+			return nil, err
+		}
+		decl := bytes.TrimSpace(src[start:end])
+		return applyChange(splice(src, start, end, nil), Change{
+			Kind:  AddDecl,
+			Src:   string(decl),
+			After: c.After,
+		})
+	default:
+		return nil, ErrUnknownChange
+	}
+}
+
+// declRange returns the [start, end) byte range of the top-level declaration identified by c.
+// It fails with ErrAmbiguousDecl if more than one declaration matches.
+func declRange(file *File, src []byte, c Change) (int, int, error) {
+	start, end, found := 0, 0, false
+	for _, child := range file.Children {
+		var s, e int
+		switch n := child.(type) {
+		case *Terminal:
+			if n.Type != c.Type || n.Name != c.Name || n.Receiver != c.Receiver {
+				continue
+			}
+			s, e = n.Span.Start, rangeEnd(src, n.Span.End)
+		case *Container:
+			if n.Type != c.Type || n.Name != c.Name || c.Receiver != "" {
+				continue
+			}
+			s, e = n.HeaderSpan.Start, rangeEnd(src, n.FooterSpan.End)
+		}
+		if found {
+			return 0, 0, ErrAmbiguousDecl
+		}
+		start, end, found = s, e, true
+	}
+	if !found {
+		return 0, 0, ErrDeclNotFound
+	}
+	return start, end, nil
+}
+
+// insertionOffset returns the offset right after the top-level declaration named after, or
+// after the last top-level declaration if after is empty.
+func insertionOffset(file *File, src []byte, after string) (int, error) {
+	for i := len(file.Children) - 1; i >= 0; i-- {
+		var name string
+		var end int
+		switch n := file.Children[i].(type) {
+		case *Terminal:
+			if n.Type == Comment {
+				continue
+			}
+			name, end = n.Name, rangeEnd(src, n.Span.End)
+		case *Container:
+			name, end = n.Name, rangeEnd(src, n.FooterSpan.End)
+		}
+		if after == "" || after == name {
+			return end, nil
+		}
+	}
+	return 0, ErrDeclNotFound
+}
+
+// rangeEnd converts an inclusive span end to an exclusive range end within src.
+func rangeEnd(src []byte, end int) int {
+	if end+1 > len(src) {
+		return len(src)
+	}
+	return end + 1
+}
+
+func splice(src []byte, start, end int, text []byte) []byte {
+	result := make([]byte, 0, len(src)-(end-start)+len(text))
+	result = append(result, src[:start]...)
+	result = append(result, text...)
+	return append(result, src[end:]...)
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,125 @@
+package smgo_test
+
+import (
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const changeSetSrc = `package changeset
+
+// A does a.
+func A() {
+}
+
+// T is a type.
+type T struct {
+	Name string
+}
+
+func B() {
+}
+`
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name        string
+		ChangeSet   smgo.ChangeSet
+		ExpectedSrc string
+	}{
+		{
+			Name: "add",
+			ChangeSet: smgo.ChangeSet{
+				{Kind: smgo.AddDecl, Src: "func C() {\n}"},
+			},
+			ExpectedSrc: changeSetSrc + "\nfunc C() {\n}\n",
+		},
+		{
+			Name: "add_after",
+			ChangeSet: smgo.ChangeSet{
+				{Kind: smgo.AddDecl, Src: "var X = 1", After: "A"},
+			},
+			ExpectedSrc: "package changeset\n\n// A does a.\nfunc A() {\n}\n\nvar X = 1\n\n// T is a type.\ntype T struct {\n\tName string\n}\n\nfunc B() {\n}\n",
+		},
+		{
+			Name: "remove",
+			ChangeSet: smgo.ChangeSet{
+				{Kind: smgo.RemoveDecl, Type: smgo.StructNode, Name: "T"},
+			},
+			ExpectedSrc: "package changeset\n\n// A does a.\nfunc A() {\n}\n\nfunc B() {\n}\n",
+		},
+		{
+			Name: "replace",
+			ChangeSet: smgo.ChangeSet{
+				{Kind: smgo.ReplaceDecl, Type: smgo.FunctionNode, Name: "A", Src: "// A does nothing.\nfunc A() {}"},
+			},
+			ExpectedSrc: "package changeset\n\n// A does nothing.\nfunc A() {}\n\n// T is a type.\ntype T struct {\n\tName string\n}\n\nfunc B() {\n}\n",
+		},
+		{
+			Name: "move",
+			ChangeSet: smgo.ChangeSet{
+				{Kind: smgo.MoveDecl, Type: smgo.FunctionNode, Name: "A"},
+			},
+			ExpectedSrc: "package changeset\n\n// T is a type.\ntype T struct {\n\tName string\n}\n\nfunc B() {\n}\n\n// A does a.\nfunc A() {\n}\n",
+		},
+		{
+			Name: "sequence",
+			ChangeSet: smgo.ChangeSet{
+				{Kind: smgo.RemoveDecl, Type: smgo.FunctionNode, Name: "B"},
+				{Kind: smgo.MoveDecl, Type: smgo.StructNode, Name: "T", After: "changeset"},
+			},
+			ExpectedSrc: "package changeset\n\n// T is a type.\ntype T struct {\n\tName string\n}\n\n// A does a.\nfunc A() {\n}\n",
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			src := []byte(changeSetSrc)
+			result, err := smgo.Apply(src, c.ChangeSet)
+			require.Nil(t, err)
+			assert.Equal(t, c.ExpectedSrc, string(result))
+			assert.Equal(t, changeSetSrc, string(src))
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := smgo.Apply([]byte(changeSetSrc), smgo.ChangeSet{
+		{Kind: smgo.RemoveDecl, Type: smgo.FunctionNode, Name: "Z"},
+	})
+	assert.Equal(t, smgo.ErrDeclNotFound, errors.Cause(err))
+
+	_, err = smgo.Apply([]byte("package"), smgo.ChangeSet{
+		{Kind: smgo.AddDecl, Src: "func A() {}"},
+	})
+	assert.Equal(t, smgo.ErrSrcHasErrors, errors.Cause(err))
+}
+
+func TestApplyMethods(t *testing.T) {
+	t.Parallel()
+
+	src := "package changeset\n\ntype T int\n\ntype U int\n\nfunc (T) String() string {\n\treturn \"T\"\n}\n\nfunc (u *U) String() string {\n\treturn \"U\"\n}\n\nfunc init() {\n}\n\nfunc init() {\n}\n"
+	result, err := smgo.Apply([]byte(src), smgo.ChangeSet{
+		{Kind: smgo.RemoveDecl, Type: smgo.FunctionNode, Name: "String", Receiver: "U"},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "package changeset\n\ntype T int\n\ntype U int\n\nfunc (T) String() string {\n\treturn \"T\"\n}\n\nfunc init() {\n}\n\nfunc init() {\n}\n", string(result))
+
+	// methods are only matched with their receivers
+	_, err = smgo.Apply([]byte(src), smgo.ChangeSet{
+		{Kind: smgo.RemoveDecl, Type: smgo.FunctionNode, Name: "String"},
+	})
+	assert.Equal(t, smgo.ErrDeclNotFound, errors.Cause(err))
+
+	_, err = smgo.Apply([]byte(src), smgo.ChangeSet{
+		{Kind: smgo.RemoveDecl, Type: smgo.FunctionNode, Name: "init"},
+	})
+	assert.Equal(t, smgo.ErrAmbiguousDecl, errors.Cause(err))
+}
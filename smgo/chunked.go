@@ -0,0 +1,117 @@
+package smgo
+
+import (
+	"go/scanner"
+	"go/token"
+)
+
+// WithChunkedParsing parses source code bigger than chunkSize bytes in chunks of about
+// chunkSize bytes, split between top-level declarations, which bounds the memory used by the
+// syntax trees of machine-generated files with many declarations. The declarations tree is
+// the same; if a chunk can't be parsed on its own, the whole source code is parsed at once.
+func WithChunkedParsing(chunkSize int) Option {
+	return func(cfg *config) {
+		cfg.chunkSize = chunkSize
+	}
+}
+
+// chunkPrefix takes the place of the package clause in the chunks after the first one.
+const chunkPrefix = "package _\n"
+
+// parseChunked is parseSrc for source code split in chunks, see WithChunkedParsing.
+func parseChunked(src []byte, isUTF8 bool, cfg *config) (*File, error) {
+	cuts := chunkCuts(src, cfg.chunkSize)
+	if len(cuts) == 0 {
+		return parseSrc(src, isUTF8, cfg)
+	}
+	file, err := parseSrc(src[:cuts[0]], isUTF8, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !isCompleteChunk(file, false) {
+		return parseSrc(src, isUTF8, cfg)
+	}
+
+	lines := lineStarts(src)
+	bounds := append(cuts, len(src))
+	for i := 0; i < len(cuts); i++ {
+		start, end := bounds[i], bounds[i+1]
+		last := end == len(src)
+		chunk := make([]byte, 0, len(chunkPrefix)+end-start)
+		chunk = append(chunk, chunkPrefix...)
+		chunk = append(chunk, src[start:end]...)
+		chunkFile, err := parseSrc(chunk, isUTF8, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if !isCompleteChunk(chunkFile, last) {
+			return parseSrc(src, isUTF8, cfg)
+		}
+
+		// chunks start at the beginning of a line, the second one of the chunk source code
+		lineDelta := offsetLocation(lines, start).Line - 2
+		offsetDelta := start - len(chunkPrefix)
+		nodes := chunkFile.Children[1:]
+		shiftNodes(nodes, func(offset int) int {
+			return offset + offsetDelta
+		}, func(l *Location) {
+			l.Line += lineDelta
+		})
+		file.Children = append(file.Children, nodes...)
+		if last {
+			file.LocationSpan.End = chunkFile.LocationSpan.End
+			file.LocationSpan.End.Line += lineDelta
+			file.FooterSpan = chunkFile.FooterSpan
+			if file.FooterSpan.End >= file.FooterSpan.Start {
+				file.FooterSpan.Start += offsetDelta
+				file.FooterSpan.End += offsetDelta
+			}
+		}
+	}
+	return file, nil
+}
+
+// isCompleteChunk reports whether the declarations tree of a chunk can be merged: it must
+// have no parsing errors and, unless it's the last chunk, end with a declaration.
+func isCompleteChunk(file *File, last bool) bool {
+	if len(file.ParsingErrors) > 0 || len(file.Children) < 2 {
+		return false
+	}
+	if last {
+		return true
+	}
+	return file.FooterSpan.End < file.FooterSpan.Start &&
+		nodeType(file.Children[len(file.Children)-1]) != Comment
+}
+
+// chunkCuts returns the offsets where src is split in chunks of about chunkSize bytes: the
+// beginning of the lines after top-level declarations ending with a newline.
+func chunkCuts(src []byte, chunkSize int) []int {
+	var cuts []int
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, 0)
+	depth, chunkStart := 0, 0
+	for {
+		pos, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			return cuts
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			depth--
+		case token.SEMICOLON:
+			offset := fset.Position(pos).Offset
+			// the automatic semicolon of a line ending with a comment is at the comment
+			if depth != 0 || lit != "\n" || offset >= len(src) || src[offset] != '\n' {
+				continue
+			}
+			cut := offset + 1
+			if cut-chunkStart >= chunkSize && cut < len(src) {
+				cuts = append(cuts, cut)
+				chunkStart = cut
+			}
+		}
+	}
+}
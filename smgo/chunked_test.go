@@ -0,0 +1,38 @@
+package smgo_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithChunkedParsing(t *testing.T) {
+	t.Parallel()
+
+	// a generated file, with comments and trailing whitespace
+	var buf bytes.Buffer
+	buf.WriteString("package chunked\n\nimport \"fmt\"\n\n// free-floating comment\n\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&buf, "// F%d does f.\nfunc F%d() {\n\tfmt.Println(%d)\n}\n\nvar V%d = %d\n", i, i, i, i, i)
+		if i%10 == 0 {
+			fmt.Fprintf(&buf, "\ntype T%d struct {\n\tA int\n} // T%d\n\n// free-floating comment\n\n", i, i)
+		}
+	}
+	buf.WriteString("\n\n")
+	srcs := append(readTestdata(t), buf.Bytes(), []byte("package chunked\n\nfunc A( {\n}\n\nfunc B() {\n}\n"))
+
+	for _, src := range srcs {
+		expected, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithStableIDs())
+		require.Nil(t, err)
+		for _, chunkSize := range []int{1, 50, 1000} {
+			file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithStableIDs(), smgo.WithChunkedParsing(chunkSize))
+			require.Nil(t, err)
+			if !assertEqualFiles(t, expected, file, "chunk size %d", chunkSize) {
+				return
+			}
+		}
+	}
+}
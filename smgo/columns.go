@@ -0,0 +1,123 @@
+package smgo
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// capColumns caps the columns of every location in file to max, if positive, returning a
+// warning for every line with capped columns.
+func capColumns(file *File, max int) []*Warning {
+	if max <= 0 {
+		return nil
+	}
+	var warnings []*Warning
+	cappedLines := make(map[int]bool)
+	capColumn := func(l *Location) {
+		if l.Column <= max {
+			return
+		}
+		if !cappedLines[l.Line] {
+			cappedLines[l.Line] = true
+			warnings = append(warnings, &Warning{
+				Location: Location{l.Line, max},
+				Message:  fmt.Sprintf("column %d exceeds the maximum column %d and was capped", l.Column, max),
+			})
+		}
+		l.Column = max
+	}
+
+	forEachLocationSpan(file, func(ls *LocationSpan) {
+		capColumn(&ls.Start)
+		capColumn(&ls.End)
+	})
+	for _, parsingError := range file.ParsingErrors {
+		capColumn(&parsingError.Location)
+	}
+	return warnings
+}
+
+// expandTabs converts the byte columns of the locations in file to visual columns, counting
+// the characters of src and expanding its tabs to the next multiple of tabWidth. The columns
+// are converted in order, so every line is scanned once.
+func expandTabs(file *File, src []byte, tabWidth int) {
+	// a column is the number of bytes before it, plus base (1 for parsing errors)
+	type column struct {
+		location *Location
+		n, base  int
+	}
+	var columns []column
+	forEachLocationSpan(file, func(ls *LocationSpan) {
+		columns = append(columns, column{&ls.Start, ls.Start.Column, 0}, column{&ls.End, ls.End.Column, 0})
+	})
+	for _, warning := range file.Warnings {
+		columns = append(columns, column{&warning.Location, warning.Location.Column, 0})
+	}
+	// parsing errors use 1-based columns
+	for _, parsingError := range file.ParsingErrors {
+		if parsingError.Location.Column > 0 {
+			columns = append(columns, column{&parsingError.Location, parsingError.Location.Column - 1, 1})
+		}
+	}
+	sort.SliceStable(columns, func(i, j int) bool {
+		if columns[i].location.Line != columns[j].location.Line {
+			return columns[i].location.Line < columns[j].location.Line
+		}
+		return columns[i].n < columns[j].n
+	})
+
+	lineStarts := lineStarts(src)
+	line, offset, width := 0, 0, 0
+	for _, c := range columns {
+		if c.location.Line < 1 || c.location.Line > len(lineStarts) {
+			continue
+		}
+		if c.location.Line != line {
+			line, offset, width = c.location.Line, lineStarts[c.location.Line-1], 0
+		}
+		for end := lineStarts[line-1] + c.n; offset < end; {
+			if offset >= len(src) {
+				width += end - offset
+				offset = end
+				break
+			}
+			r, size := utf8.DecodeRune(src[offset:])
+			if r == '\t' {
+				width += tabWidth - width%tabWidth
+			} else {
+				width++
+			}
+			offset += size
+		}
+		c.location.Column = width + c.base
+	}
+}
+
+// forEachLocationSpan calls fn with the LocationSpan of file and of every node in it.
+func forEachLocationSpan(file *File, fn func(*LocationSpan)) {
+	fn(&file.LocationSpan)
+	walkNodes(file.Children, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			fn(&n.LocationSpan)
+		case *Container:
+			fn(&n.LocationSpan)
+		}
+	})
+}
+
+// lineStarts returns the offset of every line of src.
+func lineStarts(src []byte) []int {
+	var starts []int
+	for offset := 0; ; {
+		starts = append(starts, offset)
+		i := bytes.IndexByte(src[offset:], '\n')
+		if i == -1 {
+			break
+		}
+		offset += i + 1
+	}
+	return starts
+}
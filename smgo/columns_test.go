@@ -0,0 +1,99 @@
+package smgo_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMaxColumn(t *testing.T) {
+	t.Parallel()
+
+	src := "package columns\n\nvar A = \"" + strings.Repeat("a", 200) + "\"\n"
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithMaxColumn(100))
+	require.Nil(t, err)
+	require.Len(t, file.Children, 2)
+	varA, ok := file.Children[1].(*smgo.Terminal)
+	require.True(t, ok)
+	assert.Equal(t, smgo.LocationSpan{
+		Start: smgo.Location{2, 0},
+		End:   smgo.Location{3, 100},
+	}, varA.LocationSpan)
+	assert.Equal(t, []*smgo.Warning{
+		{Location: smgo.Location{3, 100}, Message: "column 211 exceeds the maximum column 100 and was capped"},
+	}, file.Warnings)
+}
+
+func TestParseLongLine(t *testing.T) {
+	t.Parallel()
+
+	src := "package columns\n\nvar A = \"" + strings.Repeat("a", 300000) + "\"\n"
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	require.Len(t, file.Children, 2)
+	varA, ok := file.Children[1].(*smgo.Terminal)
+	require.True(t, ok)
+	// the columns aren't capped by default
+	assert.Equal(t, smgo.Location{3, 300011}, varA.LocationSpan.End)
+	assert.Empty(t, file.Warnings)
+}
+
+func TestParseTabWidth(t *testing.T) {
+	t.Parallel()
+
+	src := "package columns\n\ntype T struct {\n\tA int\n\tB,\tC string\n}\n"
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithTabWidth(4))
+	require.Nil(t, err)
+	require.Len(t, file.Children, 2)
+	typeT, ok := file.Children[1].(*smgo.Container)
+	require.True(t, ok)
+	require.Len(t, typeT.Children, 2)
+	fieldA := typeT.Children[0].(*smgo.Terminal)
+	fieldB := typeT.Children[1].(*smgo.Terminal)
+	expected := []*smgo.Terminal{
+		{
+			Type:     smgo.FieldNode,
+			Name:     "A",
+			Exported: true,
+			LocationSpan: smgo.LocationSpan{
+				Start: smgo.Location{4, 0},
+				End:   smgo.Location{4, 10},
+			},
+			Span: smgo.RuneSpan{33, 39},
+		},
+		{
+			Type:     smgo.FieldNode,
+			Name:     "B",
+			Exported: true,
+			LocationSpan: smgo.LocationSpan{
+				Start: smgo.Location{5, 0},
+				End:   smgo.Location{5, 17},
+			},
+			Span: smgo.RuneSpan{40, 52},
+		},
+	}
+	if !assert.Equal(t, expected, []*smgo.Terminal{fieldA, fieldB}) {
+		t.Log(dump(t, file))
+	}
+}
+
+func TestParseTabWidthCharacters(t *testing.T) {
+	t.Parallel()
+
+	// É takes two bytes but a single column
+	src := "package columns\n\ntype T struct {\n\tÉ int\n\tB\tstring // ñ\t\n}\n"
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithTabWidth(4))
+	require.Nil(t, err)
+	require.Len(t, file.Children, 2)
+	typeT, ok := file.Children[1].(*smgo.Container)
+	require.True(t, ok)
+	require.Len(t, typeT.Children, 2)
+	assert.Equal(t, smgo.LocationSpan{Start: smgo.Location{4, 0}, End: smgo.Location{4, 10}},
+		typeT.Children[0].(*smgo.Terminal).LocationSpan)
+	assert.Equal(t, smgo.LocationSpan{Start: smgo.Location{5, 0}, End: smgo.Location{5, 21}},
+		typeT.Children[1].(*smgo.Terminal).LocationSpan)
+	assert.Equal(t, smgo.LocationSpan{Start: smgo.Location{2, 0}, End: smgo.Location{6, 2}}, typeT.LocationSpan)
+}
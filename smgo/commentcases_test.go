@@ -5,7 +5,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/jriquelme/SemanticMergeGO/smgo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,12 +42,14 @@ func TestParseCommentCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.ConstNode,
 								Name:         "N",
+								Exported:     true,
 								LocationSpan: newLocationSpan(5, 0, 5, 7),
 								Span:         smgo.RuneSpan{51, 57},
 							},
 							&smgo.Terminal{
 								Type:         smgo.ConstNode,
 								Name:         "Name",
+								Exported:     true,
 								LocationSpan: newLocationSpan(6, 0, 8, 20),
 								Span:         smgo.RuneSpan{58, 91},
 							},
@@ -65,6 +66,7 @@ func TestParseCommentCases(t *testing.T) {
 					&smgo.Terminal{
 						Type:         smgo.ConstNode,
 						Name:         "X",
+						Exported:     true,
 						LocationSpan: newLocationSpan(13, 0, 16, 12),
 						Span:         smgo.RuneSpan{119, 158},
 					},
@@ -72,6 +74,42 @@ func TestParseCommentCases(t *testing.T) {
 				ParsingErrors: nil,
 			},
 		},
+		{
+			// comments in bodies and values, and after the last declaration
+			Src: "comment_func.go_src",
+			ExpectedFile: &smgo.File{
+				LocationSpan: newLocationSpan(1, 0, 19, 1),
+				FooterSpan:   smgo.RuneSpan{165, 183},
+				Children: []smgo.Node{
+					&smgo.Terminal{
+						Type:         smgo.PackageNode,
+						Name:         "commentfunc",
+						LocationSpan: newLocationSpan(1, 0, 1, 20),
+						Span:         smgo.RuneSpan{0, 19},
+					},
+					&smgo.Terminal{
+						Type:         smgo.FunctionNode,
+						Name:         "F",
+						Exported:     true,
+						LocationSpan: newLocationSpan(2, 0, 7, 13),
+						Span:         smgo.RuneSpan{20, 90},
+					},
+					&smgo.Terminal{
+						Type:         smgo.VarNode,
+						Name:         "v",
+						LocationSpan: newLocationSpan(8, 0, 12, 13),
+						Span:         smgo.RuneSpan{91, 131},
+					},
+					&smgo.Terminal{
+						Type:         smgo.VarNode,
+						Name:         "f",
+						LocationSpan: newLocationSpan(13, 0, 16, 2),
+						Span:         smgo.RuneSpan{132, 164},
+					},
+				},
+				ParsingErrors: nil,
+			},
+		},
 		{
 			Src: "comment_import.go_src",
 			ExpectedFile: &smgo.File{
@@ -185,30 +223,36 @@ func TestParseCommentCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "String",
+								Exported:     true,
 								LocationSpan: newLocationSpan(5, 0, 6, 23),
 								Span:         smgo.RuneSpan{52, 90},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "StringAlias",
+								Exported:     true,
 								LocationSpan: newLocationSpan(7, 0, 9, 25),
 								Span:         smgo.RuneSpan{91, 129},
+								Alias:        true,
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "Map",
+								Exported:     true,
 								LocationSpan: newLocationSpan(10, 0, 11, 21),
 								Span:         smgo.RuneSpan{130, 158},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "Array",
+								Exported:     true,
 								LocationSpan: newLocationSpan(12, 0, 13, 14),
 								Span:         smgo.RuneSpan{159, 182},
 							},
 							&smgo.Container{
 								Type:         smgo.StructNode,
 								Name:         "Person",
+								Exported:     true,
 								LocationSpan: newLocationSpan(14, 0, 21, 14),
 								HeaderSpan:   smgo.RuneSpan{183, 218},
 								FooterSpan:   smgo.RuneSpan{262, 275},
@@ -216,12 +260,14 @@ func TestParseCommentCases(t *testing.T) {
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Name",
+										Exported:     true,
 										LocationSpan: newLocationSpan(17, 0, 17, 14),
 										Span:         smgo.RuneSpan{219, 232},
 									},
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Age",
+										Exported:     true,
 										LocationSpan: newLocationSpan(18, 0, 20, 19),
 										Span:         smgo.RuneSpan{233, 261},
 									},
@@ -230,6 +276,7 @@ func TestParseCommentCases(t *testing.T) {
 							&smgo.Container{
 								Type:         smgo.InterfaceNode,
 								Name:         "Figure",
+								Exported:     true,
 								LocationSpan: newLocationSpan(22, 0, 31, 14),
 								HeaderSpan:   smgo.RuneSpan{276, 335},
 								FooterSpan:   smgo.RuneSpan{414, 448},
@@ -237,12 +284,14 @@ func TestParseCommentCases(t *testing.T) {
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Area",
+										Exported:     true,
 										LocationSpan: newLocationSpan(25, 0, 26, 24),
 										Span:         smgo.RuneSpan{336, 369},
 									},
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Perimeter",
+										Exported:     true,
 										LocationSpan: newLocationSpan(27, 0, 28, 29),
 										Span:         smgo.RuneSpan{370, 413},
 									},
@@ -261,12 +310,14 @@ func TestParseCommentCases(t *testing.T) {
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "Chan",
+						Exported:     true,
 						LocationSpan: newLocationSpan(39, 0, 41, 30),
 						Span:         smgo.RuneSpan{534, 580},
 					},
 					&smgo.Container{
 						Type:         smgo.StructNode,
 						Name:         "AnotherStruct",
+						Exported:     true,
 						LocationSpan: newLocationSpan(42, 0, 50, 2),
 						HeaderSpan:   smgo.RuneSpan{581, 627},
 						FooterSpan:   smgo.RuneSpan{706, 707},
@@ -274,12 +325,14 @@ func TestParseCommentCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.FieldNode,
 								Name:         "Func",
+								Exported:     true,
 								LocationSpan: newLocationSpan(45, 0, 46, 29),
 								Span:         smgo.RuneSpan{628, 665},
 							},
 							&smgo.Terminal{
 								Type:         smgo.FieldNode,
 								Name:         "IntPointer",
+								Exported:     true,
 								LocationSpan: newLocationSpan(47, 0, 49, 27),
 								Span:         smgo.RuneSpan{666, 705},
 							},
@@ -301,10 +354,7 @@ func TestParseCommentCases(t *testing.T) {
 			assert.NotNil(t, file)
 			assert.Nil(t, err)
 
-			assert.Equal(t, testCase.ExpectedFile, file)
-			if t.Failed() {
-				spew.Dump(t.Name(), file)
-			}
+			assertEqualFiles(t, testCase.ExpectedFile, file)
 		})
 	}
 
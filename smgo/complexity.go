@@ -0,0 +1,44 @@
+package smgo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// WithComplexity sets the cyclomatic complexity of every function and method (see
+// Terminal.Complexity).
+func WithComplexity() Option {
+	return func(cfg *config) {
+		cfg.complexity = true
+	}
+}
+
+// complexity returns the cyclomatic complexity of fd: 1 plus the number of decision points
+// in its body (if, for and range statements, non-default cases and && and || operators),
+// including the ones of its function literals.
+func complexity(fd *ast.FuncDecl) int {
+	c := 1
+	if fd.Body == nil {
+		return c
+	}
+	ast.Inspect(fd.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			c++
+		case *ast.CaseClause:
+			if n.List != nil {
+				c++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				c++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				c++
+			}
+		}
+		return true
+	})
+	return c
+}
@@ -0,0 +1,37 @@
+package smgo_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithComplexity(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Func       string
+		Complexity int
+	}{
+		{"func A() {}", 1},
+		{"func A(a int) int {\n\tif a > 0 && a < 10 || a == 20 {\n\t\treturn 1\n\t} else if a < 0 {\n\t\treturn -1\n\t}\n\treturn 0\n}", 5},
+		{"func A(s []int) {\n\tfor range s {\n\t}\n\tfor i := 0; i < 3; i++ {\n\t}\n}", 3},
+		{"func A(a int) {\n\tswitch a {\n\tcase 1, 2:\n\tcase 3:\n\tdefault:\n\t}\n}", 3},
+		{"func A(c chan int) {\n\tselect {\n\tcase <-c:\n\tdefault:\n\t}\n}", 2},
+		{"func (T) A() func(bool) {\n\treturn func(b bool) {\n\t\tif b {\n\t\t}\n\t}\n}", 2},
+	}
+	for _, c := range cases {
+		file, err := smgo.Parse(strings.NewReader("package complexity\n\n"+c.Func+"\n"), "UTF-8", smgo.WithComplexity())
+		require.Nil(t, err)
+		require.Empty(t, file.ParsingErrors)
+		require.Len(t, file.Children, 2)
+		assert.Equal(t, c.Complexity, file.Children[1].(*smgo.Terminal).Complexity, c.Func)
+	}
+
+	file, err := smgo.Parse(strings.NewReader("package complexity\n\nfunc A() {}\n"), "UTF-8")
+	require.Nil(t, err)
+	assert.Equal(t, 0, file.Children[1].(*smgo.Terminal).Complexity)
+}
@@ -7,10 +7,22 @@ type Node interface{}
 
 // File is the root of the declarations tree.
 type File struct {
-	LocationSpan  LocationSpan
+	LocationSpan LocationSpan
+	// HeaderSpan is the span of the package clause (and the imports) when they're moved out of
+	// the children, see WithFileHeader.
+	HeaderSpan    *RuneSpan
 	FooterSpan    RuneSpan
 	Children      []Node
 	ParsingErrors []*ParsingError
+	Warnings      []*Warning
+	// InvalidUTF8Policy is the policy used with invalid UTF-8 sequences.
+	InvalidUTF8Policy InvalidUTF8Policy
+	// LineEndings is the line ending style of the source code. When it's MixedLineEndings,
+	// FirstMixedLine is the first line ending differently than the first line.
+	LineEndings    LineEndings
+	FirstMixedLine int
+	// Stats are the durations of the phases of the parse, if recorded (see WithStats).
+	Stats *Stats
 }
 
 func (f *File) AddNode(node Node) {
@@ -21,6 +33,16 @@ func (f *File) Nodes() []Node {
 	return f.Children
 }
 
+// walkNodes calls fn for every node in nodes and their descendants, in depth-first order.
+func walkNodes(nodes []Node, fn func(Node)) {
+	for _, node := range nodes {
+		fn(node)
+		if c, ok := node.(*Container); ok {
+			walkNodes(c.Children, fn)
+		}
+	}
+}
+
 type NodeType int
 
 //go:generate stringer -type=NodeType
@@ -41,10 +63,15 @@ const (
 type Container struct {
 	Type         NodeType
 	Name         string
+	ID           string
+	Exported     bool
+	Deprecated   bool
 	LocationSpan LocationSpan
 	HeaderSpan   RuneSpan
 	FooterSpan   RuneSpan
 	Children     []Node
+	// Metrics are the size metrics of the container, if computed (see WithMetrics).
+	Metrics *Metrics
 }
 
 func (c *Container) AddNode(node Node) {
@@ -58,8 +85,20 @@ func (c *Container) Nodes() []Node {
 type Terminal struct {
 	Type         NodeType
 	Name         string
+	ID           string
+	Exported     bool
+	Deprecated   bool
 	LocationSpan LocationSpan
 	Span         RuneSpan
+	// Receiver is the name of the receiver type of a method, without type parameters.
+	Receiver string
+	// Alias reports whether a type declaration is an alias (type A = B).
+	Alias bool
+	// Complexity is the cyclomatic complexity of a function or method, if computed (see
+	// WithComplexity).
+	Complexity int
+	// Metrics are the size metrics of the node, if computed (see WithMetrics).
+	Metrics *Metrics
 }
 
 type ParsingError struct {
@@ -67,6 +106,12 @@ type ParsingError struct {
 	Message  string
 }
 
+// Warning is a non-fatal issue found while parsing.
+type Warning struct {
+	Location Location
+	Message  string
+}
+
 type Location struct {
 	Line   int
 	Column int
@@ -0,0 +1,60 @@
+package smgo
+
+import "go/ast"
+
+// DeclHandler maps a top-level declaration to the type and name of its node, replacing the
+// node (or nodes) built by smgo. Handlers can use their own node types, with values after
+// Comment. ok is false if the handler doesn't handle decl.
+type DeclHandler func(decl ast.Decl) (t NodeType, name string, ok bool)
+
+// WithDeclHandler adds handler to the handlers of top-level declarations, tried in order
+// before the declaration is handled by smgo. Handled declarations are Terminal nodes,
+// spanning the declaration, its doc comment and the comment in its last line, whose
+// boundaries are fixed like any other node.
+func WithDeclHandler(handler DeclHandler) Option {
+	return func(cfg *config) {
+		cfg.declHandlers = append(cfg.declHandlers, handler)
+	}
+}
+
+// handleDecl adds the node of decl if a handler of the config handles it, reporting whether
+// it was handled.
+func (v *visitor) handleDecl(decl ast.Decl) bool {
+	for _, handler := range v.Config.declHandlers {
+		t, name, ok := handler(decl)
+		if !ok {
+			continue
+		}
+		var doc *ast.CommentGroup
+		var sig ast.Node
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			doc = d.Doc
+		case *ast.FuncDecl:
+			doc, sig = d.Doc, d.Type
+		}
+		pos, end := decl.Pos(), decl.End()
+		if doc != nil {
+			pos = doc.Pos()
+		}
+		// a comment after the declaration, in its last line, is part of it
+		if cg := v.trailingComment(end); cg != nil {
+			end = cg.End()
+		}
+		// the comments of the declaration aren't free-floating comments
+		v.deleteComments(pos, end)
+		terminal := &Terminal{
+			Type:         t,
+			Name:         name,
+			Deprecated:   isDeprecated(doc),
+			LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
+			Span:         runeSpanFromPositions(v.FileSet, pos, end),
+		}
+		v.setID(terminal, "", sig)
+		ffc := v.freeFloatingCommentsBefore(terminal.Span.Start)
+		v.AddFFCToParentContainer(ffc...)
+		v.AddToParentContainer(terminal)
+		return true
+	}
+	return false
+}
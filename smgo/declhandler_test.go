@@ -0,0 +1,89 @@
+package smgo_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	assertionNode smgo.NodeType = smgo.Comment + 1 + iota
+	testNode
+)
+
+// handleAssertion handles the interface assertions like "var _ I = (*T)(nil)".
+func handleAssertion(decl ast.Decl) (smgo.NodeType, string, bool) {
+	gd, ok := decl.(*ast.GenDecl)
+	if !ok || gd.Tok != token.VAR || len(gd.Specs) != 1 {
+		return 0, "", false
+	}
+	vs := gd.Specs[0].(*ast.ValueSpec)
+	if len(vs.Names) != 1 || vs.Names[0].Name != "_" || vs.Type == nil {
+		return 0, "", false
+	}
+	return assertionNode, types.ExprString(vs.Type), true
+}
+
+// handleTest handles the test functions.
+func handleTest(decl ast.Decl) (smgo.NodeType, string, bool) {
+	fd, ok := decl.(*ast.FuncDecl)
+	if !ok || fd.Recv != nil || !strings.HasPrefix(fd.Name.Name, "Test") {
+		return 0, "", false
+	}
+	return testNode, fd.Name.Name, true
+}
+
+func TestParseWithDeclHandler(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package handlers
+
+// I is an interface.
+type I interface {
+	M()
+}
+
+// T implements I.
+var _ I = (*T)(nil) // checked
+
+// free-floating comment
+
+// TestM tests M.
+func TestM(t *testing.T) {
+	// body comment
+}
+
+func A() {
+}
+`)
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithStableIDs(),
+		smgo.WithDeclHandler(handleAssertion), smgo.WithDeclHandler(handleTest))
+	require.Nil(t, err)
+	assert.Empty(t, file.ParsingErrors)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+
+	var types []smgo.NodeType
+	var names []string
+	for _, child := range file.Children {
+		switch n := child.(type) {
+		case *smgo.Terminal:
+			types, names = append(types, n.Type), append(names, n.Name)
+			if n.Type == assertionNode || n.Type == testNode {
+				assert.NotEmpty(t, n.ID)
+				text := string(src[n.Span.Start : n.Span.End+1])
+				assert.Contains(t, text, "// T")
+			}
+		case *smgo.Container:
+			types, names = append(types, n.Type), append(names, n.Name)
+		}
+	}
+	assert.Equal(t, []smgo.NodeType{smgo.PackageNode, smgo.InterfaceNode, assertionNode, smgo.Comment, testNode, smgo.FunctionNode}, types)
+	assert.Equal(t, []string{"handlers", "I", "I", "free-float...", "TestM", "A"}, names)
+}
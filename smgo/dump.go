@@ -0,0 +1,112 @@
+package smgo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dump writes a deterministic description of file to w, one line per node indented by its
+// depth, so the dumps of two declarations trees can be compared with a line diff. Stats
+// aren't dumped.
+func Dump(w io.Writer, file *File) error {
+	d := &dumper{w: w}
+	if file.HeaderSpan != nil {
+		d.printf("File %s header %s footer %s\n", file.LocationSpan, *file.HeaderSpan, file.FooterSpan)
+	} else {
+		d.printf("File %s footer %s\n", file.LocationSpan, file.FooterSpan)
+	}
+	d.printf("InvalidUTF8Policy %s\n", file.InvalidUTF8Policy)
+	if file.LineEndings == MixedLineEndings {
+		d.printf("LineEndings %s from line %d\n", file.LineEndings, file.FirstMixedLine)
+	} else {
+		d.printf("LineEndings %s\n", file.LineEndings)
+	}
+	for _, parsingError := range file.ParsingErrors {
+		d.printf("ParsingError %s %q\n", parsingError.Location, parsingError.Message)
+	}
+	for _, warning := range file.Warnings {
+		d.printf("Warning %s %q\n", warning.Location, warning.Message)
+	}
+	d.nodes(file.Children, 1)
+	return d.err
+}
+
+// dumper writes a dump, keeping the first error.
+type dumper struct {
+	w   io.Writer
+	err error
+}
+
+func (d *dumper) printf(format string, args ...interface{}) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+func (d *dumper) nodes(nodes []Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Terminal:
+			d.printf("%s%s %q %s span %s%s%s%s%s%s%s%s\n", indent, n.Type, n.Name, n.LocationSpan, n.Span, dumpID(n.ID),
+				dumpExported(n.Exported), dumpDeprecated(n.Deprecated), dumpReceiver(n.Receiver), dumpAlias(n.Alias),
+				dumpComplexity(n.Complexity), dumpMetrics(n.Metrics))
+		case *Container:
+			d.printf("%s%s %q %s header %s footer %s%s%s%s%s\n", indent, n.Type, n.Name, n.LocationSpan, n.HeaderSpan,
+				n.FooterSpan, dumpID(n.ID), dumpExported(n.Exported), dumpDeprecated(n.Deprecated), dumpMetrics(n.Metrics))
+			d.nodes(n.Children, depth+1)
+		default:
+			d.printf("%s%T\n", indent, node)
+		}
+	}
+}
+
+func dumpID(id string) string {
+	if id == "" {
+		return ""
+	}
+	return " id " + id
+}
+
+func dumpExported(exported bool) string {
+	if !exported {
+		return ""
+	}
+	return " exported"
+}
+
+func dumpComplexity(complexity int) string {
+	if complexity == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" complexity %d", complexity)
+}
+
+func dumpMetrics(m *Metrics) string {
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf(" lines %d comment lines %d bytes %d", m.Lines, m.CommentLines, m.Bytes)
+}
+
+func dumpDeprecated(deprecated bool) string {
+	if !deprecated {
+		return ""
+	}
+	return " deprecated"
+}
+
+func dumpAlias(alias bool) string {
+	if !alias {
+		return ""
+	}
+	return " alias"
+}
+
+func dumpReceiver(receiver string) string {
+	if receiver == "" {
+		return ""
+	}
+	return " receiver " + receiver
+}
@@ -0,0 +1,29 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+
+	src := "package dump\r\n\n// T is a \xfftype.\ntype T struct {\n\tA int\n}\n"
+	file, err := smgo.Parse(bytes.NewReader([]byte(src)), "UTF-8", smgo.WithLossyDecoding('?'))
+	require.Nil(t, err)
+	var buf bytes.Buffer
+	err = smgo.Dump(&buf, file)
+	require.Nil(t, err)
+	assert.Equal(t, `File S:[L:1 C:0] E:[L:6 C:2] footer [0, -1]
+InvalidUTF8Policy InvalidUTF8Replace
+LineEndings MixedLineEndings from line 2
+Warning [L:3 C:10] "invalid encoding, replaced with '?'"
+  PackageNode "dump" S:[L:1 C:0] E:[L:1 C:13] span [0, 12]
+  StructNode "T" S:[L:1 C:13] E:[L:6 C:2] header [13, 47] footer [55, 56] exported
+    FieldNode "A" S:[L:5 C:0] E:[L:5 C:7] span [48, 54] exported
+`, buf.String())
+}
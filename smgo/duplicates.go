@@ -0,0 +1,65 @@
+package smgo
+
+import "fmt"
+
+// duplicateWarnings returns a warning for every top-level declaration of file, parsed from
+// src, named like a previous one: the parser accepts them, but they don't compile and make
+// the declarations ambiguous when matching them. The warnings are located at the start of
+// the source code of the duplicates (their doc comments, if any).
+func duplicateWarnings(file *File, src []byte) []*Warning {
+	lines := lineStarts(src)
+	declared := make(map[string]int)
+	var warnings []*Warning
+	var check func(nodes []Node)
+	check = func(nodes []Node) {
+		for _, node := range nodes {
+			var name string
+			var start int
+			var methods []Node
+			switch n := node.(type) {
+			case *Terminal:
+				if n.Type == PackageNode || n.Type == ImportNode || n.Type == Comment || n.Name == "_" ||
+					(n.Type == FunctionNode && n.Receiver == "" && n.Name == "init") {
+					continue
+				}
+				name, start = typeName(n.Name), n.Span.Start
+				if n.Receiver != "" {
+					name = n.Receiver + "." + n.Name
+				}
+			case *Container:
+				switch n.Type {
+				case ImportNode, PackageNode:
+					continue
+				case ConstNode, VarNode, TypeNode:
+					// declaration group
+					check(n.Children)
+					continue
+				}
+				name, start = typeName(n.Name), n.HeaderSpan.Start
+				// the methods grouped under the type (see WithMethodGrouping)
+				for _, child := range n.Children {
+					if t, ok := child.(*Terminal); ok && t.Receiver != "" {
+						methods = append(methods, t)
+					}
+				}
+			default:
+				continue
+			}
+			for start < len(src) && isSpace(src[start]) {
+				start++
+			}
+			location := offsetLocation(lines, start)
+			if line, ok := declared[name]; ok {
+				warnings = append(warnings, &Warning{
+					Location: location,
+					Message:  fmt.Sprintf("%s redeclared, previously declared at line %d", name, line),
+				})
+			} else {
+				declared[name] = location.Line
+			}
+			check(methods)
+		}
+	}
+	check(file.Children)
+	return warnings
+}
@@ -0,0 +1,73 @@
+package smgo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const duplicatesSrc = `package duplicates
+
+func init() {}
+
+func init() {}
+
+var _ = 1
+var _ = 2
+
+type T struct{}
+
+func (T) A() {}
+
+func (*U) A() {}
+
+func A() {}
+
+const (
+	B = 1
+	// B again.
+	B = 2
+)
+
+// T again.
+var T int
+
+func (T) A() {}
+`
+
+func TestParseDuplicates(t *testing.T) {
+	t.Parallel()
+
+	file, err := smgo.Parse(strings.NewReader(duplicatesSrc), "UTF-8")
+	require.Nil(t, err)
+	assert.Empty(t, file.ParsingErrors)
+	assert.Equal(t, []*smgo.Warning{
+		{Location: smgo.Location{20, 1}, Message: "B redeclared, previously declared at line 19"},
+		{Location: smgo.Location{24, 0}, Message: "T redeclared, previously declared at line 10"},
+		{Location: smgo.Location{27, 0}, Message: "T.A redeclared, previously declared at line 12"},
+	}, file.Warnings)
+}
+
+func TestApplyEditsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package duplicates\n\nfunc A() {}\n\nfunc B() {}\n")
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	require.Empty(t, file.Warnings)
+
+	offset := bytes.Index(src, []byte("B()"))
+	src, err = file.ApplyEdits(src, []smgo.TextEdit{{Start: offset, End: offset + 1, NewText: "A"}})
+	require.Nil(t, err)
+	assert.Equal(t, []*smgo.Warning{
+		{Location: smgo.Location{5, 0}, Message: "A redeclared, previously declared at line 3"},
+	}, file.Warnings)
+
+	src, err = file.ApplyEdits(src, []smgo.TextEdit{{Start: offset, End: offset + 1, NewText: "C"}})
+	require.Nil(t, err)
+	assert.Empty(t, file.Warnings)
+}
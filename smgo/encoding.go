@@ -0,0 +1,248 @@
+package smgo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+	"golang.org/x/text/transform"
+)
+
+// encodings are the encodings supported by Parse, by upper case name. UTF-8 needs no
+// decoding.
+var encodings = map[string]encoding.Encoding{
+	"UTF-8":          nil,
+	"WINDOWS-1252":   charmap.Windows1252,
+	"ISO-8859-1":     charmap.ISO8859_1,
+	"LATIN1":         charmap.ISO8859_1,
+	"UTF-16":         unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"UTF-16LE":       unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"UTF-16BE":       unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"UNICODEFFFE":    unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"UTF-32":         utf32.UTF32(utf32.LittleEndian, utf32.UseBOM),
+	"UTF-32LE":       utf32.UTF32(utf32.LittleEndian, utf32.UseBOM),
+	"UTF-32BE":       utf32.UTF32(utf32.BigEndian, utf32.UseBOM),
+	"EUC-KR":         korean.EUCKR,
+	"KS_C_5601-1987": korean.EUCKR,
+	"BIG5":           traditionalchinese.Big5,
+	"KOI8-R":         charmap.KOI8R,
+	"CP866":          charmap.CodePage866,
+	"IBM866":         charmap.CodePage866,
+}
+
+// lookupEncoding returns the encoding named name (case-insensitive).
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	enc, ok := encodings[strings.ToUpper(name)]
+	if !ok {
+		return nil, ErrUnsupportedEncoding
+	}
+	return enc, nil
+}
+
+// decode decodes src from enc (nil for UTF-8), returning the decoded source code and the
+// source code to parse. With lossy decoding enabled, invalid input is replaced in the source
+// code to parse, m maps its offsets back to the decoded source code, and the replacements
+// are reported as warnings. src is never modified, but it's returned as is when no decoding
+// is needed.
+func decode(src []byte, enc encoding.Encoding, cfg *config) (decoded, parsed []byte, m *offsetMap, warnings []*Warning, err error) {
+	decoded = src
+	if enc != nil {
+		decoded, err = enc.NewDecoder().Bytes(src)
+		if err != nil {
+			return nil, nil, nil, nil, errors.Wrap(err, "Error decoding src")
+		}
+	}
+	parsed = decoded
+	switch {
+	case enc == nil && cfg.invalidUTF8 == InvalidUTF8Replace:
+		parsed, m, warnings = replaceInvalid(decoded, cfg.replacement, false)
+	case enc == nil && cfg.invalidUTF8 == InvalidUTF8PassThrough:
+		parsed = maskInvalidUTF8(decoded)
+	case enc != nil && cfg.lossy:
+		parsed, m, warnings = replaceInvalid(decoded, cfg.replacement, true)
+	}
+	return decoded, parsed, m, warnings, nil
+}
+
+// replaceInvalid replaces the invalid UTF-8 sequences in src (and the utf8.RuneError runes,
+// if runeErrors is true) with replacement, returning the map from the offsets of the result
+// to the offsets of src (nil if nothing is replaced) and a warning located in src for every
+// replacement.
+func replaceInvalid(src []byte, replacement rune, runeErrors bool) ([]byte, *offsetMap, []*Warning) {
+	var warnings []*Warning
+	var buf [utf8.UTFMax]byte
+	replacementBytes := buf[:utf8.EncodeRune(buf[:], replacement)]
+	result := make([]byte, 0, len(src))
+	m := &offsetMap{}
+	line, lineStart := 1, 0
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRune(src[i:])
+		if r == utf8.RuneError && (size == 1 || runeErrors) {
+			warnings = append(warnings, &Warning{
+				Location: Location{
+					Line:   line,
+					Column: i - lineStart,
+				},
+				Message: fmt.Sprintf("invalid encoding, replaced with %q", replacement),
+			})
+			m.hunks = append(m.hunks, hunk{i, i + size, len(result), len(result) + len(replacementBytes)})
+			result = append(result, replacementBytes...)
+		} else {
+			result = append(result, src[i:i+size]...)
+		}
+		i += size
+		if r == '\n' {
+			line++
+			lineStart = i
+		}
+	}
+	if len(m.hunks) == 0 {
+		return result, nil, nil
+	}
+	return result, m, warnings
+}
+
+// firstInvalidUTF8 returns the location of the first invalid UTF-8 sequence in src, if any,
+// with a 1-based column like the parsing errors.
+func firstInvalidUTF8(src []byte) (Location, bool) {
+	line, lineStart := 1, 0
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRune(src[i:])
+		if r == utf8.RuneError && size == 1 {
+			return Location{line, i - lineStart + 1}, true
+		}
+		i += size
+		if r == '\n' {
+			line++
+			lineStart = i
+		}
+	}
+	return Location{}, false
+}
+
+// maskInvalidUTF8 returns a copy of src with every byte of its invalid UTF-8 sequences
+// replaced with '?', so offsets in the copy are offsets in src too.
+func maskInvalidUTF8(src []byte) []byte {
+	if utf8.Valid(src) {
+		return src
+	}
+	masked := make([]byte, len(src))
+	copy(masked, src)
+	for i := 0; i < len(masked); {
+		r, size := utf8.DecodeRune(masked[i:])
+		if r == utf8.RuneError && size == 1 {
+			masked[i] = '?'
+		}
+		i += size
+	}
+	return masked
+}
+
+// encodedOffsets returns the offset in src, encoded with enc, of every byte of decoded (and
+// of its end), see WithEncodedOffsets. The bytes of a rune are at the offset of the rune.
+// src is decoded again one rune at a time, so the undecodable bytes replaced by the decoder
+// are mapped too.
+func encodedOffsets(src, decoded []byte, enc encoding.Encoding) ([]int, error) {
+	decoder := enc.NewDecoder()
+	offsets := make([]int, len(decoded)+1)
+	var buf [utf8.UTFMax]byte
+	nSrc := 0
+	for i := 0; i < len(decoded); {
+		// a buffer of the size of the next rune holds that rune only; the bytes decoded
+		// without output (like the BOM) belong to the next rune
+		start, nDst := nSrc, 0
+		for size := 1; nDst == 0; {
+			if size > len(buf) || nSrc == len(src) {
+				return nil, errors.New("Error mapping offsets to the encoding")
+			}
+			n, consumed, err := decoder.Transform(buf[:size], src[nSrc:], true)
+			if err != nil && err != transform.ErrShortDst {
+				return nil, errors.Wrap(err, "Error mapping offsets to the encoding")
+			}
+			nSrc += consumed
+			nDst = n
+			if n == 0 && consumed == 0 {
+				size++
+			}
+		}
+		if i+nDst > len(decoded) || !bytes.Equal(buf[:nDst], decoded[i:i+nDst]) {
+			return nil, errors.New("Error mapping offsets to the encoding")
+		}
+		for j := 0; j < nDst; j++ {
+			offsets[i+j] = start
+		}
+		i += nDst
+	}
+	offsets[len(decoded)] = len(src)
+	return offsets, nil
+}
+
+// encodeOffsets maps the spans and locations of file, parsed from decoded, to the offsets of
+// src, its encoded source code, see WithEncodedOffsets. Byte columns are mapped only if
+// mapColumns is true.
+func encodeOffsets(file *File, src, decoded []byte, enc encoding.Encoding, mapColumns bool) error {
+	offsets, err := encodedOffsets(src, decoded, enc)
+	if err != nil {
+		return err
+	}
+	mapSpan := func(span *RuneSpan) {
+		if span.End < span.Start {
+			// empty span
+			span.Start = offsets[span.Start]
+			span.End = span.Start - 1
+			return
+		}
+		span.Start, span.End = offsets[span.Start], offsets[span.End+1]-1
+	}
+	mapSpan(&file.FooterSpan)
+	if file.HeaderSpan != nil {
+		mapSpan(file.HeaderSpan)
+	}
+	walkNodes(file.Children, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			mapSpan(&n.Span)
+		case *Container:
+			mapSpan(&n.HeaderSpan)
+			mapSpan(&n.FooterSpan)
+		}
+	})
+	if !mapColumns {
+		return nil
+	}
+
+	lines := lineStarts(decoded)
+	mapLocation := func(l *Location) {
+		offset := locationOffset(lines, *l)
+		if offset > len(decoded) {
+			return
+		}
+		lineStart := offsets[locationOffset(lines, Location{l.Line, 0})]
+		l.Column = offsets[offset] - lineStart
+	}
+	forEachLocationSpan(file, func(ls *LocationSpan) {
+		mapLocation(&ls.Start)
+		mapLocation(&ls.End)
+	})
+	for _, warning := range file.Warnings {
+		mapLocation(&warning.Location)
+	}
+	// parsing errors use 1-based columns
+	for _, parsingError := range file.ParsingErrors {
+		if parsingError.Location.Column > 0 {
+			l := &parsingError.Location
+			l.Column--
+			mapLocation(l)
+			l.Column++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,444 @@
+package smgo_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+func TestParseEncodings(t *testing.T) {
+	t.Parallel()
+	if testing.Verbose() {
+		smgo.PrintBlocks = true
+	}
+
+	src, err := ioutil.ReadFile("testdata/simple_struct.go")
+	require.Nil(t, err)
+	expectedFile, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+
+	cases := []struct {
+		Name     string
+		Encoding string
+		Encoder  encoding.Encoding
+	}{
+		{"utf32", "UTF-32", utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)},
+		{"utf32_bom", "UTF-32", utf32.UTF32(utf32.LittleEndian, utf32.UseBOM)},
+		{"utf32_be_bom", "UTF-32", utf32.UTF32(utf32.BigEndian, utf32.UseBOM)},
+		{"utf32le", "utf-32le", utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)},
+		{"utf32be", "UTF-32BE", utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM)},
+		{"utf32be_bom", "UTF-32BE", utf32.UTF32(utf32.BigEndian, utf32.UseBOM)},
+		{"utf16_bom", "UTF-16", unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)},
+		{"utf16le", "UTF-16LE", unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)},
+		{"utf16be_bom", "UTF-16BE", unicode.UTF16(unicode.BigEndian, unicode.UseBOM)},
+		{"unicodefffe", "unicodeFFFE", unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			encodedSrc, err := c.Encoder.NewEncoder().Bytes(src)
+			require.Nil(t, err)
+
+			file, err := smgo.Parse(bytes.NewReader(encodedSrc), c.Encoding)
+			assert.Nil(t, err)
+			assertEqualFiles(t, expectedFile, file)
+		})
+	}
+}
+
+func TestParseLegacyEncodings(t *testing.T) {
+	t.Parallel()
+	if testing.Verbose() {
+		smgo.PrintBlocks = true
+	}
+
+	cases := []struct {
+		Src          string
+		Encoding     string
+		ExpectedFile *smgo.File
+	}{
+		{
+			Src:      "encoding_euckr.go_src",
+			Encoding: "EUC-KR",
+			ExpectedFile: &smgo.File{
+				LocationSpan: newLocationSpan(1, 0, 13, 2),
+				FooterSpan:   smgo.RuneSpan{0, -1},
+				Children: []smgo.Node{
+					&smgo.Terminal{
+						Type:         smgo.PackageNode,
+						Name:         "hangul",
+						LocationSpan: newLocationSpan(1, 0, 1, 15),
+						Span:         smgo.RuneSpan{0, 14},
+					},
+					&smgo.Terminal{
+						Type:         smgo.FunctionNode,
+						Name:         "Hi",
+						Exported:     true,
+						LocationSpan: newLocationSpan(2, 0, 6, 2),
+						Span:         smgo.RuneSpan{15, 69},
+					},
+					&smgo.Terminal{
+						Type:         smgo.Comment,
+						Name:         "사람 구조체의 주석...",
+						LocationSpan: newLocationSpan(7, 0, 8, 39),
+						Span:         smgo.RuneSpan{70, 109},
+					},
+					&smgo.Container{
+						Type:         smgo.StructNode,
+						Name:         "Person",
+						Exported:     true,
+						LocationSpan: newLocationSpan(9, 0, 13, 2),
+						HeaderSpan:   smgo.RuneSpan{110, 141},
+						FooterSpan:   smgo.RuneSpan{165, 166},
+						Children: []smgo.Node{
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "Name",
+								Exported:     true,
+								LocationSpan: newLocationSpan(12, 0, 12, 23),
+								Span:         smgo.RuneSpan{142, 164},
+							},
+						},
+					},
+				},
+				ParsingErrors: nil,
+			},
+		},
+		{
+			Src:      "encoding_big5.go_src",
+			Encoding: "Big5",
+			ExpectedFile: &smgo.File{
+				LocationSpan: newLocationSpan(1, 0, 13, 2),
+				FooterSpan:   smgo.RuneSpan{0, -1},
+				Children: []smgo.Node{
+					&smgo.Terminal{
+						Type:         smgo.PackageNode,
+						Name:         "hanzi",
+						LocationSpan: newLocationSpan(1, 0, 1, 14),
+						Span:         smgo.RuneSpan{0, 13},
+					},
+					&smgo.Terminal{
+						Type:         smgo.FunctionNode,
+						Name:         "Hi",
+						Exported:     true,
+						LocationSpan: newLocationSpan(2, 0, 6, 2),
+						Span:         smgo.RuneSpan{14, 58},
+					},
+					&smgo.Terminal{
+						Type:         smgo.Comment,
+						Name:         "這是人的結構的註解",
+						LocationSpan: newLocationSpan(7, 0, 8, 31),
+						Span:         smgo.RuneSpan{59, 90},
+					},
+					&smgo.Container{
+						Type:         smgo.StructNode,
+						Name:         "Person",
+						Exported:     true,
+						LocationSpan: newLocationSpan(9, 0, 13, 2),
+						HeaderSpan:   smgo.RuneSpan{91, 119},
+						FooterSpan:   smgo.RuneSpan{143, 144},
+						Children: []smgo.Node{
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "Name",
+								Exported:     true,
+								LocationSpan: newLocationSpan(12, 0, 12, 23),
+								Span:         smgo.RuneSpan{120, 142},
+							},
+						},
+					},
+				},
+				ParsingErrors: nil,
+			},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Encoding, func(t *testing.T) {
+			srcFile, err := os.Open("testdata/" + c.Src)
+			require.Nil(t, err)
+			defer srcFile.Close()
+
+			file, err := smgo.Parse(srcFile, c.Encoding)
+			assert.NotNil(t, file)
+			assert.Nil(t, err)
+
+			assertEqualFiles(t, c.ExpectedFile, file)
+		})
+	}
+}
+
+func TestParseCyrillicEncodings(t *testing.T) {
+	t.Parallel()
+	if testing.Verbose() {
+		smgo.PrintBlocks = true
+	}
+
+	src, err := ioutil.ReadFile("testdata/encoding_cyrillic.go")
+	require.Nil(t, err)
+	expectedFile, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+
+	cases := []struct {
+		Encoding string
+		Encoder  encoding.Encoding
+	}{
+		{"KOI8-R", charmap.KOI8R},
+		{"CP866", charmap.CodePage866},
+		{"ibm866", charmap.CodePage866},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Encoding, func(t *testing.T) {
+			encodedSrc, err := c.Encoder.NewEncoder().Bytes(src)
+			require.Nil(t, err)
+
+			file, err := smgo.Parse(bytes.NewReader(encodedSrc), c.Encoding)
+			assert.Nil(t, err)
+			assertEqualFiles(t, expectedFile, file)
+		})
+	}
+}
+
+func TestParseEncodedOffsets(t *testing.T) {
+	t.Parallel()
+
+	src, err := ioutil.ReadFile("testdata/simple_struct.go")
+	require.Nil(t, err)
+
+	cases := []struct {
+		Name     string
+		Encoding string
+		Encoder  encoding.Encoding
+		BOM      int
+	}{
+		{"utf16_bom", "UTF-16", unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), 2},
+		{"utf16be_bom", "UTF-16BE", unicode.UTF16(unicode.BigEndian, unicode.UseBOM), 2},
+		{"utf16be", "UTF-16BE", unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), 0},
+		{"utf32_bom", "UTF-32", utf32.UTF32(utf32.LittleEndian, utf32.UseBOM), 4},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			// every byte of the ASCII source code takes the same number of bytes encoded
+			width := 2
+			if c.Encoding == "UTF-32" {
+				width = 4
+			}
+			expectedFile, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+			require.Nil(t, err)
+			encodeSpans(expectedFile, c.BOM, width)
+			encodedSrc, err := c.Encoder.NewEncoder().Bytes(src)
+			require.Nil(t, err)
+
+			file, err := smgo.Parse(bytes.NewReader(encodedSrc), c.Encoding, smgo.WithEncodedOffsets())
+			require.Nil(t, err)
+			assertEqualFiles(t, expectedFile, file)
+			assert.Empty(t, smgo.CheckSpans(file, len(encodedSrc)))
+		})
+	}
+
+	// Latin-1 takes a byte for "é", two in UTF-8
+	latin1Src, err := charmap.ISO8859_1.NewEncoder().String("package latin\n\n// Café\nfunc Café() {\n}\n")
+	require.Nil(t, err)
+	file, err := smgo.Parse(strings.NewReader(latin1Src), "ISO-8859-1", smgo.WithEncodedOffsets())
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(latin1Src)))
+	require.Len(t, file.Children, 2)
+	cafe := file.Children[1].(*smgo.Terminal)
+	assert.Equal(t, "Café", cafe.Name)
+	assert.Equal(t, newLocationSpan(2, 0, 5, 2), cafe.LocationSpan)
+	assert.Equal(t, smgo.RuneSpan{14, len(latin1Src) - 1}, cafe.Span)
+
+	// the bytes replaced by the decoder can't be encoded again, but they're mapped too
+	eucKRSrc := "package lossy\n\n// bad \xff byte\nfunc A() {\n}\n"
+	file, err = smgo.Parse(strings.NewReader(eucKRSrc), "EUC-KR", smgo.WithLossyDecoding('?'),
+		smgo.WithEncodedOffsets())
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(eucKRSrc)))
+	require.Len(t, file.Children, 2)
+	assert.Equal(t, smgo.RuneSpan{14, len(eucKRSrc) - 1}, file.Children[1].(*smgo.Terminal).Span)
+
+	// visual columns don't depend on the encoding
+	file, err = smgo.Parse(strings.NewReader(latin1Src), "latin1", smgo.WithEncodedOffsets(), smgo.WithTabWidth(4))
+	require.Nil(t, err)
+	assert.Equal(t, smgo.RuneSpan{14, len(latin1Src) - 1}, file.Children[1].(*smgo.Terminal).Span)
+}
+
+// encodeSpans maps the spans and columns of file, parsed from ASCII source code, to the ones
+// of the source code encoded with width bytes per character after a BOM of bom bytes.
+func encodeSpans(file *smgo.File, bom, width int) {
+	mapSpan := func(span *smgo.RuneSpan) {
+		// the BOM is part of the spans starting at 0
+		start := 0
+		if span.Start > 0 {
+			start = bom + span.Start*width
+		}
+		if span.End < span.Start {
+			span.Start, span.End = start, start-1
+			return
+		}
+		span.Start, span.End = start, bom+(span.End+1)*width-1
+	}
+	mapLocation := func(l *smgo.Location) {
+		if l.Line == 1 && l.Column > 0 {
+			l.Column = bom + l.Column*width
+			return
+		}
+		l.Column *= width
+	}
+	mapLocationSpan := func(ls *smgo.LocationSpan) {
+		mapLocation(&ls.Start)
+		mapLocation(&ls.End)
+	}
+	mapSpan(&file.FooterSpan)
+	mapLocationSpan(&file.LocationSpan)
+	for _, node := range file.Children {
+		switch n := node.(type) {
+		case *smgo.Terminal:
+			mapSpan(&n.Span)
+			mapLocationSpan(&n.LocationSpan)
+		case *smgo.Container:
+			mapSpan(&n.HeaderSpan)
+			mapSpan(&n.FooterSpan)
+			mapLocationSpan(&n.LocationSpan)
+			for _, child := range n.Children {
+				field := child.(*smgo.Terminal)
+				mapSpan(&field.Span)
+				mapLocationSpan(&field.LocationSpan)
+			}
+		}
+	}
+}
+
+func TestParseLossyDecoding(t *testing.T) {
+	t.Parallel()
+	if testing.Verbose() {
+		smgo.PrintBlocks = true
+	}
+
+	src := "package lossy\n\n// bad \xff byte\nfunc A() {\n\tprint(\"a\")\n}\n"
+
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Len(t, file.ParsingErrors, 1)
+	assert.Nil(t, file.Warnings)
+
+	cases := []struct {
+		Name             string
+		Encoding         string
+		Replacement      rune
+		ExpectedWarnings []*smgo.Warning
+	}{
+		{
+			Name:        "utf8",
+			Encoding:    "UTF-8",
+			Replacement: utf8.RuneError,
+			ExpectedWarnings: []*smgo.Warning{
+				{Location: smgo.Location{3, 7}, Message: "invalid encoding, replaced with '�'"},
+			},
+		},
+		{
+			Name:        "utf8_question_mark",
+			Encoding:    "UTF-8",
+			Replacement: '?',
+			ExpectedWarnings: []*smgo.Warning{
+				{Location: smgo.Location{3, 7}, Message: "invalid encoding, replaced with '?'"},
+			},
+		},
+		{
+			Name:        "euckr",
+			Encoding:    "EUC-KR",
+			Replacement: '?',
+			ExpectedWarnings: []*smgo.Warning{
+				{Location: smgo.Location{3, 7}, Message: "invalid encoding, replaced with '?'"},
+			},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			file, err := smgo.Parse(strings.NewReader(src), c.Encoding, smgo.WithLossyDecoding(c.Replacement))
+			require.Nil(t, err)
+			assert.Nil(t, file.ParsingErrors)
+			assert.Equal(t, c.ExpectedWarnings, file.Warnings)
+			require.Len(t, file.Children, 2)
+			assert.Equal(t, "A", file.Children[1].(*smgo.Terminal).Name)
+			if c.Encoding == "UTF-8" {
+				// the spans are offsets of the source code, not of the replacements
+				assert.Empty(t, smgo.CheckSpans(file, len(src)))
+				assert.Equal(t, smgo.RuneSpan{14, len(src) - 1}, file.Children[1].(*smgo.Terminal).Span)
+			}
+			if t.Failed() {
+				t.Log(dump(t, file))
+			}
+		})
+	}
+}
+
+func TestParseInvalidUTF8Policies(t *testing.T) {
+	t.Parallel()
+	if testing.Verbose() {
+		smgo.PrintBlocks = true
+	}
+
+	src := "package invalid\n\n// bad \xff byte\nfunc A() {\n\tprint(\"\xfe\")\n}\n"
+
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Equal(t, &smgo.File{
+		LocationSpan: newLocationSpan(1, 0, 1, 0),
+		FooterSpan:   smgo.RuneSpan{0, -1},
+		ParsingErrors: []*smgo.ParsingError{
+			{Location: smgo.Location{3, 8}, Message: "invalid UTF-8 encoding"},
+		},
+		InvalidUTF8Policy: smgo.InvalidUTF8Error,
+	}, file)
+
+	file, err = smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithInvalidUTF8Policy(smgo.InvalidUTF8Replace))
+	require.Nil(t, err)
+	assert.Nil(t, file.ParsingErrors)
+	assert.Equal(t, smgo.InvalidUTF8Replace, file.InvalidUTF8Policy)
+	assert.Equal(t, []*smgo.Warning{
+		{Location: smgo.Location{3, 7}, Message: "invalid encoding, replaced with '�'"},
+		{Location: smgo.Location{5, 8}, Message: "invalid encoding, replaced with '�'"},
+	}, file.Warnings)
+	require.Len(t, file.Children, 2)
+	assert.Equal(t, smgo.RuneSpan{16, 55}, file.Children[1].(*smgo.Terminal).Span)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+
+	file, err = smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithInvalidUTF8Policy(smgo.InvalidUTF8PassThrough))
+	require.Nil(t, err)
+	assert.Nil(t, file.ParsingErrors)
+	assert.Nil(t, file.Warnings)
+	assert.Equal(t, smgo.InvalidUTF8PassThrough, file.InvalidUTF8Policy)
+	require.Len(t, file.Children, 2)
+	assert.Equal(t, smgo.RuneSpan{16, 55}, file.Children[1].(*smgo.Terminal).Span)
+
+	// the columns of the invalid sequences are 1-based, like the ones of other parsing errors
+	file, err = smgo.Parse(strings.NewReader("package invalid\n\xff\n"), "UTF-8")
+	require.Nil(t, err)
+	require.Len(t, file.ParsingErrors, 1)
+	assert.Equal(t, smgo.Location{2, 1}, file.ParsingErrors[0].Location)
+	file, err = smgo.Parse(strings.NewReader("package invalid\n\n//\t\xff\n"), "UTF-8", smgo.WithTabWidth(4))
+	require.Nil(t, err)
+	require.Len(t, file.ParsingErrors, 1)
+	assert.Equal(t, smgo.Location{3, 5}, file.ParsingErrors[0].Location)
+
+	// invalid UTF-8 is only accepted in comments and string literals
+	file, err = smgo.Parse(strings.NewReader("package invalid\n\nvar \xff = 1\n"), "UTF-8",
+		smgo.WithInvalidUTF8Policy(smgo.InvalidUTF8PassThrough))
+	require.Nil(t, err)
+	assert.Len(t, file.ParsingErrors, 1)
+}
@@ -0,0 +1,160 @@
+package smgo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Fragments of statements are parsed as the body of a function.
+const (
+	stmtsPrefix = chunkPrefix + "func _() {\n"
+	stmtsSuffix = "\n}\n"
+)
+
+// ParseFragment parses the UTF-8 encoded fragment of GO source code in src, a bare list of
+// top-level declarations or of statements without package clause, as found in documentation
+// and REPL-like editors. The root of its declarations tree is a synthetic File, with the
+// spans and locations of the fragment. The nodes of a list of statements are its declaration
+// statements (const, var and type declarations); the other statements are part of the spans
+// of the nodes around them. If src is neither, the parsing errors are the ones of the list of
+// declarations. Options about the encoding, preprocessing, chunks and file headers don't
+// apply.
+func ParseFragment(src []byte, opts ...Option) (*File, error) {
+	cfg := newConfig(opts)
+	if cfg.invalidUTF8 == InvalidUTF8Error {
+		location, invalid := firstInvalidUTF8(src)
+		if invalid {
+			return newErrorFile(location, errorMessage(location, "invalid UTF-8 encoding", cfg)), nil
+		}
+	}
+
+	file, err := parseFragment(src, chunkPrefix, "", cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.ParsingErrors) > 0 {
+		stmtsFile, err := parseFragment(src, stmtsPrefix, stmtsSuffix, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if len(stmtsFile.ParsingErrors) == 0 {
+			file = stmtsFile
+		}
+	}
+
+	file.InvalidUTF8Policy = cfg.invalidUTF8
+	file.LineEndings, file.FirstMixedLine = auditLineEndings(src)
+	if cfg.nfcNames {
+		normalizeNames(file.Children)
+	}
+	if cfg.names != nil {
+		cfg.names.intern(file.Children)
+	}
+	file.Warnings = append(file.Warnings, duplicateWarnings(file, src)...)
+	file.Warnings = append(file.Warnings, iotaWarnings(file, src)...)
+	if cfg.metrics {
+		setMetrics(file.Children, src)
+	}
+	if cfg.tabWidth > 0 {
+		expandTabs(file, src, cfg.tabWidth)
+	}
+	file.Warnings = append(file.Warnings, capColumns(file, cfg.maxColumn)...)
+	if cfg.strict {
+		err = checkSpec(file, len(src))
+		if err != nil {
+			return nil, err
+		}
+	}
+	reportWarnings(file, cfg)
+	return file, nil
+}
+
+// parseFragment builds the declarations tree of src, parsed between prefix and suffix. If
+// suffix is empty src is a list of declarations, otherwise a list of statements.
+func parseFragment(src []byte, prefix, suffix string, cfg *config) (*File, error) {
+	wrapped := make([]byte, 0, len(prefix)+len(src)+len(suffix))
+	wrapped = append(wrapped, prefix...)
+	wrapped = append(wrapped, src...)
+	wrapped = append(wrapped, suffix...)
+	prefixLines := strings.Count(prefix, "\n")
+
+	fset := token.NewFileSet()
+	fileAST, err := parser.ParseFile(fset, "", wrapped, parser.ParseComments)
+	if err != nil {
+		if list, ok := err.(scanner.ErrorList); ok {
+			for _, e := range list {
+				e.Pos.Line -= prefixLines
+			}
+		}
+		return newErrorFile(Location{1, 0}, parseErrorMessage(err, cfg)), nil
+	}
+	base := fset.File(fileAST.FileStart).Base()
+
+	v := newVisitor(fset, fileAST, cfg)
+	if suffix == "" {
+		for _, decl := range fileAST.Decls {
+			if !v.handleDecl(decl) {
+				ast.Walk(v, decl)
+			}
+		}
+	} else {
+		body := fileAST.Decls[0].(*ast.FuncDecl).Body
+		for _, stmt := range body.List {
+			if ds, ok := stmt.(*ast.DeclStmt); ok {
+				ast.Walk(v, ds.Decl)
+				continue
+			}
+			// the comments of other statements aren't free-floating comments
+			v.deleteComments(stmt.Pos(), stmt.End())
+		}
+	}
+	ffc := v.freeFloatingCommentsBefore(len(prefix) + len(src) + 1)
+	v.AddFFCToParentContainer(ffc...)
+
+	err = fixBlockBoundaries(fset, base, v.File, wrapped)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading fixing boundaries")
+	}
+	setExported(v.File.Children)
+
+	// drop the synthetic package clause, and the prefix and suffix from the spans
+	file := v.File
+	file.Children = file.Children[1:]
+	file.LocationSpan = LocationSpan{Start: Location{1, 0}, End: Location{1, 0}}
+	last := len(src) - 1
+	if len(src) > 0 {
+		file.LocationSpan.End = offsetLocation(lineStarts(src), last)
+		file.LocationSpan.End.Column++
+	}
+	end := file.LocationSpan.End
+	shiftNodes(file.Children, func(offset int) int {
+		offset -= len(prefix)
+		if offset < 0 {
+			offset = 0
+		}
+		if offset > last {
+			offset = last
+		}
+		return offset
+	}, func(l *Location) {
+		l.Line -= prefixLines
+		if l.Line < 1 {
+			*l = Location{1, 0}
+		}
+		if l.Line > end.Line || l.Line == end.Line && l.Column > end.Column {
+			*l = end
+		}
+	})
+	file.FooterSpan = RuneSpan{len(src), last}
+	if len(file.Children) == 0 {
+		file.FooterSpan.Start = 0
+	} else if _, end := nodeRange(file.Children[len(file.Children)-1]); end < last {
+		file.FooterSpan.Start = end + 1
+	}
+	return file, nil
+}
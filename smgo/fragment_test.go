@@ -0,0 +1,73 @@
+package smgo_test
+
+import (
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFragmentDeclarations(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("// Hello says hi.\nfunc Hello() {\n}\n\ntype T struct {\n\tA int\n}\n")
+	file, err := smgo.ParseFragment(src)
+	require.Nil(t, err)
+	assert.Empty(t, file.ParsingErrors)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	assert.Equal(t, newLocationSpan(1, 0, 7, 2), file.LocationSpan)
+	assert.Equal(t, smgo.RuneSpan{61, 60}, file.FooterSpan)
+	require.Len(t, file.Children, 2)
+
+	hello := file.Children[0].(*smgo.Terminal)
+	assert.Equal(t, smgo.FunctionNode, hello.Type)
+	assert.Equal(t, "Hello", hello.Name)
+	assert.Equal(t, newLocationSpan(1, 0, 3, 2), hello.LocationSpan)
+	assert.Equal(t, smgo.RuneSpan{0, 34}, hello.Span)
+
+	structT := file.Children[1].(*smgo.Container)
+	assert.Equal(t, smgo.StructNode, structT.Type)
+	assert.Equal(t, newLocationSpan(4, 0, 7, 2), structT.LocationSpan)
+	assert.Equal(t, smgo.RuneSpan{35, 51}, structT.HeaderSpan)
+	assert.Equal(t, smgo.RuneSpan{59, 60}, structT.FooterSpan)
+}
+
+func TestParseFragmentStatements(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("x := 1\nconst c = 2\n// comment\nfor i := 0; i < x; i++ {\n\t// not free-floating\n}\ntype T struct {\n\tA int\n}")
+	file, err := smgo.ParseFragment(src)
+	require.Nil(t, err)
+	assert.Empty(t, file.ParsingErrors)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	assert.Equal(t, newLocationSpan(1, 0, 9, 1), file.LocationSpan)
+	require.Len(t, file.Children, 3)
+
+	c := file.Children[0].(*smgo.Terminal)
+	assert.Equal(t, smgo.ConstNode, c.Type)
+	assert.Equal(t, "c", c.Name)
+	assert.Equal(t, smgo.RuneSpan{0, 18}, c.Span)
+	comment := file.Children[1].(*smgo.Terminal)
+	assert.Equal(t, smgo.Comment, comment.Type)
+	structT := file.Children[2].(*smgo.Container)
+	assert.Equal(t, "T", structT.Name)
+	assert.Equal(t, newLocationSpan(4, 0, 9, 1), structT.LocationSpan)
+	assert.Equal(t, smgo.RuneSpan{len(src) - 1, len(src) - 1}, structT.FooterSpan)
+}
+
+func TestParseFragmentErrors(t *testing.T) {
+	t.Parallel()
+
+	file, err := smgo.ParseFragment([]byte("func (\n"))
+	require.Nil(t, err)
+	require.Len(t, file.ParsingErrors, 1)
+	// the location of the error is the one in the fragment
+	assert.Equal(t, "1:8: expected ')', found 'EOF'", file.ParsingErrors[0].Message)
+
+	file, err = smgo.ParseFragment(nil)
+	require.Nil(t, err)
+	assert.Empty(t, file.ParsingErrors)
+	assert.Empty(t, file.Children)
+	assert.Equal(t, smgo.RuneSpan{0, -1}, file.FooterSpan)
+}
@@ -5,7 +5,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/jriquelme/SemanticMergeGO/smgo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,12 +42,14 @@ func TestParseGroupedCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.ConstNode,
 								Name:         "N",
+								Exported:     true,
 								LocationSpan: newLocationSpan(4, 0, 4, 17),
 								Span:         smgo.RuneSpan{30, 46},
 							},
 							&smgo.Terminal{
 								Type:         smgo.ConstNode,
 								Name:         "Name",
+								Exported:     true,
 								LocationSpan: newLocationSpan(5, 0, 5, 20),
 								Span:         smgo.RuneSpan{47, 66},
 							},
@@ -145,60 +146,71 @@ func TestParseGroupedCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "String",
+								Exported:     true,
 								LocationSpan: newLocationSpan(6, 0, 6, 15),
 								Span:         smgo.RuneSpan{41, 55},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "StringAlias",
+								Exported:     true,
 								LocationSpan: newLocationSpan(7, 0, 8, 22),
 								Span:         smgo.RuneSpan{56, 78},
+								Alias:        true,
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "Map",
+								Exported:     true,
 								LocationSpan: newLocationSpan(9, 0, 9, 29),
 								Span:         smgo.RuneSpan{79, 107},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "Array",
+								Exported:     true,
 								LocationSpan: newLocationSpan(10, 0, 10, 20),
 								Span:         smgo.RuneSpan{108, 127},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "Chan",
+								Exported:     true,
 								LocationSpan: newLocationSpan(11, 0, 12, 17),
 								Span:         smgo.RuneSpan{128, 145},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "Func",
+								Exported:     true,
 								LocationSpan: newLocationSpan(13, 0, 14, 19),
 								Span:         smgo.RuneSpan{146, 165},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "IntPointer",
+								Exported:     true,
 								LocationSpan: newLocationSpan(15, 0, 16, 17),
 								Span:         smgo.RuneSpan{166, 183},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "RedundantPar",
+								Exported:     true,
 								LocationSpan: newLocationSpan(17, 0, 18, 21),
 								Span:         smgo.RuneSpan{184, 205},
 							},
 							&smgo.Terminal{
 								Type:         smgo.TypeNode,
 								Name:         "Reader",
+								Exported:     true,
 								LocationSpan: newLocationSpan(19, 0, 20, 18),
 								Span:         smgo.RuneSpan{206, 224},
 							},
 							&smgo.Container{
 								Type:         smgo.StructNode,
 								Name:         "Person",
+								Exported:     true,
 								LocationSpan: newLocationSpan(21, 0, 26, 3),
 								HeaderSpan:   smgo.RuneSpan{225, 242},
 								FooterSpan:   smgo.RuneSpan{268, 270},
@@ -206,12 +218,14 @@ func TestParseGroupedCases(t *testing.T) {
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Name",
+										Exported:     true,
 										LocationSpan: newLocationSpan(23, 0, 23, 14),
 										Span:         smgo.RuneSpan{243, 256},
 									},
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Age",
+										Exported:     true,
 										LocationSpan: newLocationSpan(24, 0, 25, 10),
 										Span:         smgo.RuneSpan{257, 267},
 									},
@@ -220,6 +234,7 @@ func TestParseGroupedCases(t *testing.T) {
 							&smgo.Container{
 								Type:         smgo.InterfaceNode,
 								Name:         "Figure",
+								Exported:     true,
 								LocationSpan: newLocationSpan(27, 0, 31, 3),
 								HeaderSpan:   smgo.RuneSpan{271, 291},
 								FooterSpan:   smgo.RuneSpan{331, 333},
@@ -227,12 +242,14 @@ func TestParseGroupedCases(t *testing.T) {
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Area",
+										Exported:     true,
 										LocationSpan: newLocationSpan(29, 0, 29, 17),
 										Span:         smgo.RuneSpan{292, 308},
 									},
 									&smgo.Terminal{
 										Type:         smgo.FieldNode,
 										Name:         "Perimeter",
+										Exported:     true,
 										LocationSpan: newLocationSpan(30, 0, 30, 22),
 										Span:         smgo.RuneSpan{309, 330},
 									},
@@ -274,12 +291,14 @@ func TestParseGroupedCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.VarNode,
 								Name:         "X",
+								Exported:     true,
 								LocationSpan: newLocationSpan(4, 0, 4, 7),
 								Span:         smgo.RuneSpan{26, 32},
 							},
 							&smgo.Terminal{
 								Type:         smgo.VarNode,
 								Name:         "Z",
+								Exported:     true,
 								LocationSpan: newLocationSpan(5, 0, 6, 18),
 								Span:         smgo.RuneSpan{33, 51},
 							},
@@ -309,10 +328,7 @@ func TestParseGroupedCases(t *testing.T) {
 			assert.NotNil(t, file)
 			assert.Nil(t, err)
 
-			assert.Equal(t, testCase.ExpectedFile, file)
-			if t.Failed() {
-				spew.Dump(t.Name(), file)
-			}
+			assertEqualFiles(t, testCase.ExpectedFile, file)
 		})
 	}
 
@@ -0,0 +1,89 @@
+package smgo
+
+// WithFileHeader moves the package clause, and the imports if imports is true, from the
+// children of the file to its header (see File.HeaderSpan), along with the free-floating
+// comments among them, so the header, the children and the footer of the file partition its
+// source code like the ones of a container.
+func WithFileHeader(imports bool) Option {
+	return func(cfg *config) {
+		cfg.fileHeader = packageInHeader
+		if imports {
+			cfg.fileHeader = importsInHeader
+		}
+	}
+}
+
+// WithHeaderContainer groups the package clause and the imports, along with the
+// free-floating comments among them, in a PackageNode container, whose header is the package
+// clause and whose footer is empty, like the containers of the parsers of other languages.
+// Only the last of WithFileHeader and WithHeaderContainer applies.
+func WithHeaderContainer() Option {
+	return func(cfg *config) {
+		cfg.fileHeader = headerContainer
+	}
+}
+
+// headerMode is the handling of the package clause and the imports of the files, see
+// WithFileHeader and WithHeaderContainer.
+type headerMode int
+
+const (
+	noFileHeader headerMode = iota
+	packageInHeader
+	importsInHeader
+	headerContainer
+)
+
+// setFileHeader moves the leading package clause (and imports) of file to its header, or to
+// a header container, according to mode.
+func setFileHeader(file *File, mode headerMode) {
+	if mode == noFileHeader || len(file.Children) == 0 || nodeType(file.Children[0]) != PackageNode {
+		return
+	}
+	last := 0
+	for i, child := range file.Children {
+		t := nodeType(child)
+		if t == PackageNode || mode != packageInHeader && t == ImportNode {
+			last = i
+		} else if t != Comment {
+			break
+		}
+	}
+	header := file.Children[:last+1]
+	rest := append([]Node(nil), file.Children[last+1:]...)
+	pkg, ok := header[0].(*Terminal)
+	if !ok {
+		return
+	}
+	_, end := nodeRange(header[last])
+	if mode != headerContainer {
+		file.HeaderSpan = &RuneSpan{pkg.Span.Start, end}
+		file.Children = rest
+		return
+	}
+	container := &Container{
+		Type:         PackageNode,
+		Name:         pkg.Name,
+		ID:           pkg.ID,
+		LocationSpan: pkg.LocationSpan,
+		HeaderSpan:   pkg.Span,
+		FooterSpan:   RuneSpan{end + 1, end},
+		Children:     append([]Node(nil), header[1:]...),
+	}
+	switch n := header[last].(type) {
+	case *Terminal:
+		container.LocationSpan.End = n.LocationSpan.End
+	case *Container:
+		container.LocationSpan.End = n.LocationSpan.End
+	}
+	file.Children = append([]Node{container}, rest...)
+}
+
+// hasHeaderContainer reports whether file has a header container, see WithHeaderContainer.
+func hasHeaderContainer(file *File) bool {
+	if len(file.Children) == 0 {
+		return false
+	}
+	c, ok := file.Children[0].(*Container)
+	return ok && c.Type == PackageNode
+}
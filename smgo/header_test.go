@@ -0,0 +1,96 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithFileHeader(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package header\n\n// free-floating comment\n\nimport \"fmt\"\n\nimport (\n\t\"os\"\n)\n\nfunc A() {\n\tfmt.Println(os.Args)\n}\n")
+	cases := []struct {
+		Imports    bool
+		HeaderEnd  string
+		FirstChild smgo.NodeType
+	}{
+		{false, "package header\n", smgo.Comment},
+		{true, "\t\"os\"\n)\n", smgo.FunctionNode},
+	}
+	for _, c := range cases {
+		file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithFileHeader(c.Imports))
+		require.Nil(t, err)
+		require.NotNil(t, file.HeaderSpan)
+		assert.Equal(t, smgo.RuneSpan{0, bytes.Index(src, []byte(c.HeaderEnd)) + len(c.HeaderEnd) - 1}, *file.HeaderSpan)
+		require.NotEmpty(t, file.Children)
+		switch n := file.Children[0].(type) {
+		case *smgo.Terminal:
+			assert.Equal(t, c.FirstChild, n.Type)
+		case *smgo.Container:
+			assert.Equal(t, c.FirstChild, n.Type)
+		}
+		assert.Empty(t, smgo.CheckSpans(file, len(src)))
+
+		// incremental parses keep the header
+		newSrc, err := file.ApplyEdits(src, []smgo.TextEdit{{len(src), len(src), "\nfunc B() {\n}\n"}}, smgo.WithFileHeader(c.Imports))
+		require.Nil(t, err)
+		expected, err := smgo.Parse(bytes.NewReader(newSrc), "UTF-8", smgo.WithFileHeader(c.Imports))
+		require.Nil(t, err)
+		assertEqualFiles(t, expected, file)
+	}
+
+	// without the option, the file has no header
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Nil(t, file.HeaderSpan)
+	assert.Equal(t, smgo.PackageNode, file.Children[0].(*smgo.Terminal).Type)
+}
+
+func TestParseWithHeaderContainer(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package header\n\n// free-floating comment\n\nimport \"fmt\"\n\nimport (\n\t\"os\"\n)\n\nfunc A() {\n\tfmt.Println(os.Args)\n}\n")
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithHeaderContainer())
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	require.Len(t, file.Children, 2)
+	header, ok := file.Children[0].(*smgo.Container)
+	require.True(t, ok)
+	assert.Equal(t, smgo.PackageNode, header.Type)
+	assert.Equal(t, "header", header.Name)
+	assert.Equal(t, newLocationSpan(1, 0, 9, 2), header.LocationSpan)
+	assert.Equal(t, smgo.RuneSpan{0, 14}, header.HeaderSpan)
+	assert.Equal(t, smgo.RuneSpan{73, 72}, header.FooterSpan)
+	var types []smgo.NodeType
+	for _, child := range header.Children {
+		switch n := child.(type) {
+		case *smgo.Terminal:
+			types = append(types, n.Type)
+		case *smgo.Container:
+			types = append(types, n.Type)
+		}
+	}
+	assert.Equal(t, []smgo.NodeType{smgo.Comment, smgo.ImportNode, smgo.ImportNode}, types)
+	assert.Equal(t, "A", file.Children[1].(*smgo.Terminal).Name)
+
+	// incremental parses keep the header container
+	newSrc, err := file.ApplyEdits(src, []smgo.TextEdit{{len(src), len(src), "\nfunc B() {\n}\n"}}, smgo.WithHeaderContainer())
+	require.Nil(t, err)
+	expected, err := smgo.Parse(bytes.NewReader(newSrc), "UTF-8", smgo.WithHeaderContainer())
+	require.Nil(t, err)
+	assertEqualFiles(t, expected, file)
+
+	// a package without imports
+	src = []byte("package header\n\nfunc A() {\n}\n")
+	file, err = smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithHeaderContainer())
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	header = file.Children[0].(*smgo.Container)
+	assert.Empty(t, header.Children)
+	assert.Equal(t, smgo.RuneSpan{0, 14}, header.HeaderSpan)
+	assert.Equal(t, smgo.RuneSpan{15, 14}, header.FooterSpan)
+}
@@ -0,0 +1,95 @@
+package smgo
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"go/ast"
+	"go/printer"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NodeID returns a stable identifier for a declaration of type t, computed from its
+// qualified name (e.g. "Person.SayHi") and its normalized signature. The identifier doesn't
+// depend on the position of the declaration, so it can be used to track a declaration
+// across revisions of a file.
+func NodeID(t NodeType, qualifiedName, signature string) string {
+	h := sha1.New()
+	h.Write([]byte(t.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(qualifiedName))
+	h.Write([]byte{0})
+	h.Write([]byte(signature))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// setID sets the ID of node when stable IDs are enabled (comments and declaration groups
+// have no ID). The name of node is qualified with
+// qualifier (if any) and the names of its enclosing struct or interface; the signature is
+// the normalized source code of sig (if any). With WithNFCNames, both are NFC-normalized.
+func (v *visitor) setID(node Node, qualifier string, sig ast.Node) {
+	if !v.Config.stableIDs {
+		return
+	}
+	var names []string
+	for _, pn := range v.containerStack {
+		if c, ok := pn.(*Container); ok && (c.Type == StructNode || c.Type == InterfaceNode) {
+			names = append(names, c.Name)
+		}
+	}
+	if qualifier != "" {
+		names = append(names, qualifier)
+	}
+	signature := ""
+	if sig != nil {
+		var buf bytes.Buffer
+		err := printer.Fprint(&buf, v.FileSet, sig)
+		if err == nil {
+			signature = buf.String()
+		}
+	}
+	var t NodeType
+	switch n := node.(type) {
+	case *Terminal:
+		t, names = n.Type, append(names, n.Name)
+	case *Container:
+		t, names = n.Type, append(names, n.Name)
+	}
+	qualifiedName := strings.Join(names, ".")
+	if v.Config.nfcNames {
+		qualifiedName, signature = norm.NFC.String(qualifiedName), norm.NFC.String(signature)
+	}
+	id := NodeID(t, qualifiedName, signature)
+	switch n := node.(type) {
+	case *Terminal:
+		n.ID = id
+	case *Container:
+		n.ID = id
+	}
+}
+
+// receiverName returns the name of the receiver type of fd, or "" if fd is a function.
+func receiverName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return ""
+	}
+	expr := fd.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
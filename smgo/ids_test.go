@@ -0,0 +1,81 @@
+package smgo_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithStableIDs(t *testing.T) {
+	t.Parallel()
+
+	src1 := "package ids\n\ntype Person struct {\n\tName string\n}\n\nfunc (p *Person) SayHi() {\n}\n\nfunc SayHi() {\n}\n"
+	src2 := "package ids\n\n// SayHi says hi.\nfunc SayHi() {\n\tprint(\"hi\")\n}\n\nfunc (p *Person) SayHi() {\n}\n\ntype Person struct {\n\tAge  int\n\tName string\n}\n"
+
+	file1, err := smgo.Parse(strings.NewReader(src1), "UTF-8", smgo.WithStableIDs())
+	require.Nil(t, err)
+	file2, err := smgo.Parse(strings.NewReader(src2), "UTF-8", smgo.WithStableIDs())
+	require.Nil(t, err)
+
+	person1 := file1.Children[1].(*smgo.Container)
+	method1 := file1.Children[2].(*smgo.Terminal)
+	func1 := file1.Children[3].(*smgo.Terminal)
+	func2 := file2.Children[1].(*smgo.Terminal)
+	method2 := file2.Children[2].(*smgo.Terminal)
+	person2 := file2.Children[3].(*smgo.Container)
+
+	assert.NotEmpty(t, file1.Children[0].(*smgo.Terminal).ID)
+	assert.Equal(t, file1.Children[0].(*smgo.Terminal).ID, file2.Children[0].(*smgo.Terminal).ID)
+	assert.Equal(t, person1.ID, person2.ID)
+	assert.Equal(t, person1.Children[0].(*smgo.Terminal).ID, person2.Children[1].(*smgo.Terminal).ID)
+	assert.Equal(t, method1.ID, method2.ID)
+	assert.Equal(t, func1.ID, func2.ID)
+	assert.NotEqual(t, method1.ID, func1.ID)
+	assert.Equal(t, smgo.NodeID(smgo.FunctionNode, "Person.SayHi", "func()"), method1.ID)
+}
+
+func TestParseWithoutStableIDs(t *testing.T) {
+	t.Parallel()
+
+	file, err := smgo.Parse(strings.NewReader("package ids\n\nfunc A() {\n}\n"), "UTF-8")
+	require.Nil(t, err)
+	for _, child := range file.Children {
+		assert.Empty(t, child.(*smgo.Terminal).ID)
+	}
+}
+
+func TestNodeID(t *testing.T) {
+	t.Parallel()
+
+	id := smgo.NodeID(smgo.FunctionNode, "A", "func(a int)")
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, smgo.NodeID(smgo.FunctionNode, "A", "func(a int)"))
+	assert.NotEqual(t, id, smgo.NodeID(smgo.FunctionNode, "A", "func(a string)"))
+	assert.NotEqual(t, id, smgo.NodeID(smgo.VarNode, "A", "func(a int)"))
+}
+
+func TestParseWithNFCNames(t *testing.T) {
+	t.Parallel()
+
+	// Hangul syllables decompose to letters in NFD, so both forms are valid identifiers
+	nfc := "package ids\n\nfunc \ud55c() {\n}\n"
+	nfd := "package ids\n\nfunc \u1112\u1161\u11ab() {\n}\n"
+
+	file, err := smgo.Parse(strings.NewReader(nfd), "UTF-8")
+	require.Nil(t, err)
+	assert.Equal(t, "\u1112\u1161\u11ab", file.Children[1].(*smgo.Terminal).Name)
+
+	opts := []smgo.Option{smgo.WithNFCNames(), smgo.WithStableIDs()}
+	nfcFile, err := smgo.Parse(strings.NewReader(nfc), "UTF-8", opts...)
+	require.Nil(t, err)
+	nfdFile, err := smgo.Parse(strings.NewReader(nfd), "UTF-8", opts...)
+	require.Nil(t, err)
+	nfcFunc := nfcFile.Children[1].(*smgo.Terminal)
+	nfdFunc := nfdFile.Children[1].(*smgo.Terminal)
+	assert.Equal(t, "\ud55c", nfcFunc.Name)
+	assert.Equal(t, nfcFunc.Name, nfdFunc.Name)
+	assert.Equal(t, nfcFunc.ID, nfdFunc.ID)
+}
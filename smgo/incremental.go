@@ -0,0 +1,222 @@
+package smgo
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidEdits = errors.New("Invalid text edits")
+
+// TextEdit replaces the bytes [Start, End) of a source code with NewText.
+type TextEdit struct {
+	Start   int
+	End     int
+	NewText string
+}
+
+// ApplyEdits applies edits (sorted by offset and not overlapping) to src, the UTF-8 source
+// code f was parsed from, updating f to the declarations tree of the resulting source code,
+// which is returned. opts must be the options f was parsed with.
+//
+// Only the top-level declarations whose spans intersect the edits are parsed again; the
+// offsets and locations of the others are shifted. f is fully parsed again when the edits
+// touch the package clause or the imports, when the source code has parsing errors or
+// warnings, or when the options transform the source code or its columns.
+func (f *File) ApplyEdits(src []byte, edits []TextEdit, opts ...Option) ([]byte, error) {
+	start := time.Now()
+	newSrc, err := applyEdits(src, edits)
+	if err != nil {
+		return nil, err
+	}
+	cfg := newConfig(opts)
+	if len(edits) == 0 || !f.reparseEdited(src, newSrc, edits, cfg) {
+		file, err := Parse(bytes.NewReader(newSrc), "UTF-8", opts...)
+		if err != nil {
+			return nil, err
+		}
+		*f = *file
+	} else {
+		if cfg.strict {
+			err = checkSpec(f, len(newSrc))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if cfg.recordStats {
+			cfg.stats.Total = time.Since(start)
+			f.Stats = &cfg.stats
+		}
+		reportWarnings(f, cfg)
+	}
+	return newSrc, nil
+}
+
+func applyEdits(src []byte, edits []TextEdit) ([]byte, error) {
+	var buf bytes.Buffer
+	offset := 0
+	for _, edit := range edits {
+		if edit.Start < offset || edit.End < edit.Start || edit.End > len(src) {
+			return nil, ErrInvalidEdits
+		}
+		buf.Write(src[offset:edit.Start])
+		buf.WriteString(edit.NewText)
+		offset = edit.End
+	}
+	buf.Write(src[offset:])
+	return buf.Bytes(), nil
+}
+
+// reparseEdited parses again the top-level declarations of f intersecting edits, returning
+// false if f has to be fully parsed again instead.
+func (f *File) reparseEdited(src, newSrc []byte, edits []TextEdit, cfg *config) bool {
+	if len(f.ParsingErrors) > 0 || len(f.Warnings) > 0 || len(cfg.transformers) > 0 ||
+		f.HeaderSpan != nil || cfg.fileHeader != noFileHeader || hasHeaderContainer(f) || cfg.groupMethods ||
+		cfg.tabWidth > 0 || cfg.maxColumn > 0 || cfg.invalidUTF8 != InvalidUTF8Error ||
+		len(newSrc) == 0 {
+		return false
+	}
+
+	// find the edited declarations, [first, last]
+	first, last, lastImport := -1, -1, -1
+	for i, child := range f.Children {
+		start, end := nodeRange(child)
+		for _, edit := range edits {
+			if edit.Start <= end+1 && edit.End >= start {
+				if first == -1 {
+					first = i
+				}
+				last = i
+			}
+		}
+		if t := nodeType(child); t == PackageNode || t == ImportNode {
+			lastImport = i
+		}
+	}
+	footerEdited := false
+	if f.FooterSpan.End >= f.FooterSpan.Start {
+		lastEdit := edits[len(edits)-1]
+		footerEdited = lastEdit.End >= f.FooterSpan.Start
+	}
+	if first <= lastImport || footerEdited {
+		return false
+	}
+
+	// parse the edited region alone, after a package clause taking the place of the
+	// previous declaration
+	regionStart, _ := nodeRange(f.Children[first])
+	_, regionEnd := nodeRange(f.Children[last])
+	regionEnd++
+	delta := len(newSrc) - len(src)
+	newRegionEnd := regionEnd + delta
+	if newRegionEnd < regionStart {
+		return false
+	}
+	srcLines, newLines := lineStarts(src), lineStarts(newSrc)
+	regionLocation := offsetLocation(newLines, regionStart)
+	prefix := "package _;"
+	if regionLocation.Column == 0 {
+		prefix = "package _\n"
+	}
+	fragment := append([]byte(prefix), newSrc[regionStart:newRegionEnd]...)
+	fragmentFile, err := parseSrc(fragment, true, cfg)
+	if err != nil || len(fragmentFile.ParsingErrors) > 0 || len(fragmentFile.Children) < 2 ||
+		fragmentFile.FooterSpan.End >= fragmentFile.FooterSpan.Start {
+		return false
+	}
+	// a trailing comment could be the doc comment of the next declaration
+	if nodeType(fragmentFile.Children[len(fragmentFile.Children)-1]) == Comment {
+		return false
+	}
+	nodes := fragmentFile.Children[1:]
+	if cfg.nfcNames {
+		normalizeNames(nodes)
+	}
+
+	// move the new declarations to the region
+	shiftNodes(nodes, func(offset int) int {
+		return offset - len(prefix) + regionStart
+	}, func(l *Location) {
+		if prefix == "package _\n" {
+			l.Line += regionLocation.Line - 2
+			return
+		}
+		if l.Line == 1 {
+			l.Column += regionLocation.Column - len(prefix)
+		}
+		l.Line += regionLocation.Line - 1
+	})
+
+	// shift the declarations after the region
+	oldEnd := offsetLocation(srcLines, regionEnd)
+	newEnd := offsetLocation(newLines, newRegionEnd)
+	after := f.Children[last+1:]
+	shiftNodes(after, func(offset int) int {
+		return offset + delta
+	}, func(l *Location) {
+		if l.Line == oldEnd.Line {
+			l.Column += newEnd.Column - oldEnd.Column
+		}
+		l.Line += newEnd.Line - oldEnd.Line
+	})
+	if f.FooterSpan.End >= f.FooterSpan.Start {
+		f.FooterSpan.Start += delta
+		f.FooterSpan.End += delta
+	}
+
+	if cfg.metrics {
+		setMetrics(nodes, newSrc)
+	}
+
+	children := make([]Node, 0, first+len(nodes)+len(after))
+	children = append(children, f.Children[:first]...)
+	children = append(children, nodes...)
+	f.Children = append(children, after...)
+	end := offsetLocation(newLines, len(newSrc)-1)
+	f.LocationSpan.End = Location{end.Line, end.Column + 1}
+	f.LineEndings, f.FirstMixedLine = auditLineEndings(newSrc)
+	f.Warnings = append(duplicateWarnings(f, newSrc), iotaWarnings(f, newSrc)...)
+	return true
+}
+
+// nodeRange returns the inclusive range of offsets of node.
+func nodeRange(node Node) (int, int) {
+	switch n := node.(type) {
+	case *Terminal:
+		return n.Span.Start, n.Span.End
+	case *Container:
+		return n.HeaderSpan.Start, n.FooterSpan.End
+	}
+	return 0, -1
+}
+
+func nodeType(node Node) NodeType {
+	switch n := node.(type) {
+	case *Terminal:
+		return n.Type
+	case *Container:
+		return n.Type
+	}
+	return Comment
+}
+
+// shiftNodes maps the offsets and locations of nodes and their descendants.
+func shiftNodes(nodes []Node, mapOffset func(int) int, mapLocation func(*Location)) {
+	mapSpan := func(span *RuneSpan) {
+		span.Start, span.End = mapOffset(span.Start), mapOffset(span.End)
+	}
+	walkNodes(nodes, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			mapSpan(&n.Span)
+			mapLocation(&n.LocationSpan.Start)
+			mapLocation(&n.LocationSpan.End)
+		case *Container:
+			mapSpan(&n.HeaderSpan)
+			mapSpan(&n.FooterSpan)
+			mapLocation(&n.LocationSpan.Start)
+			mapLocation(&n.LocationSpan.End)
+		}
+	})
+}
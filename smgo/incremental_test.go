@@ -0,0 +1,92 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const incrementalSrc = `package incremental
+
+import "fmt"
+
+// A does a.
+func A() {
+	fmt.Println("a")
+}
+
+// T is a type.
+type T struct {
+	Name string
+}
+
+// free-floating comment
+
+var X = 1; var Y = 2
+
+func B() {
+}
+`
+
+func TestApplyEdits(t *testing.T) {
+	t.Parallel()
+
+	offset := func(s string) int {
+		i := bytes.Index([]byte(incrementalSrc), []byte(s))
+		require.NotEqual(t, -1, i, s)
+		return i
+	}
+	cases := []struct {
+		Name  string
+		Edits []smgo.TextEdit
+	}{
+		{"none", nil},
+		{"rename_func", []smgo.TextEdit{{offset("A()"), offset("A()") + 1, "AA"}}},
+		{"func_body", []smgo.TextEdit{{offset(`"a"`), offset(`"a"`) + 3, "\"a\"\n\tfmt.Println(\"more\")"}}},
+		{"add_field", []smgo.TextEdit{{offset("\tName"), offset("\tName"), "\tAge  int\n"}}},
+		{"doc_comment", []smgo.TextEdit{{offset("// T is"), offset("// T is") + 2, "//\n//"}}},
+		{"same_line", []smgo.TextEdit{{offset("2\n"), offset("2\n") + 1, "22222"}}},
+		{"new_decl", []smgo.TextEdit{{offset("func B"), offset("func B"), "const C = 3\n\n"}}},
+		{"multiple", []smgo.TextEdit{
+			{offset("A()"), offset("A()") + 1, "Z"},
+			{offset("func B"), offset("func B") + 6, "func BB"},
+		}},
+		{"remove_decl", []smgo.TextEdit{{offset("// A does"), offset("// T is"), ""}}},
+		{"parsing_error", []smgo.TextEdit{{offset("A()"), offset("A()") + 3, "A("}}},
+		{"package", []smgo.TextEdit{{offset("incremental"), offset("incremental") + 11, "inc"}}},
+		{"import", []smgo.TextEdit{{offset(`"fmt"`), offset(`"fmt"`) + 5, `"fmt"; import "os"`}}},
+		{"trailing_comment", []smgo.TextEdit{{offset("func B"), offset("func B"), "// B does b.\n"}}},
+		{"end", []smgo.TextEdit{{len(incrementalSrc), len(incrementalSrc), "\nfunc C() {}\n"}}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			src := []byte(incrementalSrc)
+			opts := []smgo.Option{smgo.WithStableIDs(), smgo.WithComplexity(), smgo.WithMetrics()}
+			file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", opts...)
+			require.Nil(t, err)
+			newSrc, err := file.ApplyEdits(src, c.Edits, opts...)
+			require.Nil(t, err)
+			expected, err := smgo.Parse(bytes.NewReader(newSrc), "UTF-8", opts...)
+			require.Nil(t, err)
+			assertEqualFiles(t, expected, file)
+		})
+	}
+}
+
+func TestApplyEditsErrors(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package incremental\n")
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	_, err = file.ApplyEdits(src, []smgo.TextEdit{{5, 4, ""}})
+	assert.Equal(t, smgo.ErrInvalidEdits, err)
+	_, err = file.ApplyEdits(src, []smgo.TextEdit{{5, 6, ""}, {0, 1, ""}})
+	assert.Equal(t, smgo.ErrInvalidEdits, err)
+	_, err = file.ApplyEdits(src, []smgo.TextEdit{{0, len(src) + 1, ""}})
+	assert.Equal(t, smgo.ErrInvalidEdits, err)
+}
@@ -0,0 +1,59 @@
+package smgo
+
+import "fmt"
+
+// CheckSpans checks that the spans of file partition its source code of srcLen bytes: the
+// header of the file (if any), every terminal, container header, container footer and the
+// footer of the file start right after the previous one, in order, and the last one ends at
+// the end of the source code. It returns a warning for every violation. Files with parsing
+// errors aren't checked.
+func CheckSpans(file *File, srcLen int) []*Warning {
+	if len(file.ParsingErrors) > 0 {
+		return nil
+	}
+	var warnings []*Warning
+	var blocks []block
+	addBlocksFrom(file, &blocks)
+	offset := 0
+	check := func(what string, span RuneSpan, location Location) {
+		if span.Start != offset {
+			warnings = append(warnings, &Warning{
+				Location: location,
+				Message:  fmt.Sprintf("%s starts at %d, expected %d", what, span.Start, offset),
+			})
+		}
+		if span.End < span.Start-1 {
+			warnings = append(warnings, &Warning{
+				Location: location,
+				Message:  fmt.Sprintf("%s ends at %d, before its start %d", what, span.End, span.Start),
+			})
+		}
+		offset = span.End + 1
+	}
+	if file.HeaderSpan != nil {
+		check("header of the file", *file.HeaderSpan, file.LocationSpan.Start)
+	}
+	for _, b := range blocks {
+		switch b.Type {
+		case nodeBlock:
+			n := b.Terminal()
+			check(fmt.Sprintf("span of %s %q", n.Type, n.Name), n.Span, n.LocationSpan.Start)
+		case containerHeader:
+			n := b.Container()
+			check(fmt.Sprintf("header of %s %q", n.Type, n.Name), n.HeaderSpan, n.LocationSpan.Start)
+		case containerFooter:
+			n := b.Container()
+			check(fmt.Sprintf("footer of %s %q", n.Type, n.Name), n.FooterSpan, n.LocationSpan.End)
+		}
+	}
+	if file.FooterSpan.End >= file.FooterSpan.Start {
+		check("footer of the file", file.FooterSpan, file.LocationSpan.End)
+	}
+	if offset != srcLen {
+		warnings = append(warnings, &Warning{
+			Location: file.LocationSpan.End,
+			Message:  fmt.Sprintf("spans end at %d, expected %d", offset, srcLen),
+		})
+	}
+	return warnings
+}
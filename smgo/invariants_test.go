@@ -0,0 +1,32 @@
+package smgo_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSpans(t *testing.T) {
+	t.Parallel()
+
+	src := "package invariants\n\n// T is a type.\ntype T struct {\n\tName string\n}\n\nfunc A() {\n}\n\n// the end\n"
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+
+	typeT := file.Children[1].(*smgo.Container)
+	typeT.Children[0].(*smgo.Terminal).Span.Start++
+	assert.Equal(t, []*smgo.Warning{
+		{
+			Location: smgo.Location{5, 0},
+			Message:  `span of FieldNode "Name" starts at 53, expected 52`,
+		},
+		{
+			Location: file.LocationSpan.End,
+			Message:  "spans end at 93, expected 94",
+		},
+	}, smgo.CheckSpans(file, len(src)+1))
+}
@@ -0,0 +1,43 @@
+package smgo
+
+import "bytes"
+
+// LineEndings is the line ending style of a source code.
+type LineEndings int
+
+//go:generate stringer -type=LineEndings
+
+const (
+	// LFLineEndings means every line ends with "\n" (or there is a single line).
+	LFLineEndings LineEndings = iota
+	// CRLFLineEndings means every line ends with "\r\n".
+	CRLFLineEndings
+	// MixedLineEndings means some lines end with "\n" and others with "\r\n".
+	MixedLineEndings
+)
+
+// auditLineEndings returns the line ending style of src and, if mixed, the number of the
+// first line ending differently than the first line.
+func auditLineEndings(src []byte) (LineEndings, int) {
+	var first LineEndings
+	seen := false
+	for offset, line := 0, 1; ; line++ {
+		i := bytes.IndexByte(src[offset:], '\n')
+		if i == -1 {
+			break
+		}
+		lineEnding := LFLineEndings
+		if i > 0 && src[offset+i-1] == '\r' {
+			lineEnding = CRLFLineEndings
+		}
+		switch {
+		case !seen:
+			first = lineEnding
+			seen = true
+		case lineEnding != first:
+			return MixedLineEndings, line
+		}
+		offset += i + 1
+	}
+	return first, 0
+}
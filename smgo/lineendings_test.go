@@ -0,0 +1,37 @@
+package smgo_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLineEndings(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Name                   string
+		Src                    string
+		ExpectedLineEndings    smgo.LineEndings
+		ExpectedFirstMixedLine int
+	}{
+		{"single_line", "package lineendings", smgo.LFLineEndings, 0},
+		{"lf", "package lineendings\n\nvar A = 1\n", smgo.LFLineEndings, 0},
+		{"crlf", "package lineendings\r\n\r\nvar A = 1\r\n", smgo.CRLFLineEndings, 0},
+		{"mixed_lf", "package lineendings\n\nvar A = 1\r\n", smgo.MixedLineEndings, 3},
+		{"mixed_crlf", "package lineendings\r\n\nvar A = 1\r\n", smgo.MixedLineEndings, 2},
+		{"parsing_error", "package\r\n\n", smgo.MixedLineEndings, 2},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			file, err := smgo.Parse(strings.NewReader(c.Src), "UTF-8")
+			require.Nil(t, err)
+			assert.Equal(t, c.ExpectedLineEndings, file.LineEndings)
+			assert.Equal(t, c.ExpectedFirstMixedLine, file.FirstMixedLine)
+		})
+	}
+}
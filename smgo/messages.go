@@ -0,0 +1,66 @@
+package smgo
+
+import (
+	"go/scanner"
+	"strconv"
+	"strings"
+)
+
+// ErrorMessage renders the message of a parsing error, so the messages of go/parser and smgo
+// can be shown in the language of the user. location is the position of the error (with the
+// 1-based column of go/parser for syntax errors, or {0, 0} if it's unknown) and msg its
+// original message, without the position.
+type ErrorMessage func(location Location, msg string) string
+
+// WithErrorMessages renders the messages of the parsing errors with render. The locations of
+// the parsing errors don't change.
+func WithErrorMessages(render ErrorMessage) Option {
+	return func(cfg *config) {
+		cfg.errorMessage = render
+	}
+}
+
+// TemplateMessages returns an ErrorMessage rendering the messages starting with a key of
+// templates (the longest one) with its template, where {line}, {column} and {message} are
+// replaced with the line, the column and the original message. Other messages are rendered
+// as "{line}:{column}: {message}". For instance:
+//
+//	smgo.TemplateMessages(map[string]string{
+//		"expected": "{line}:{column}: se esperaba otro símbolo ({message})",
+//	})
+func TemplateMessages(templates map[string]string) ErrorMessage {
+	return func(location Location, msg string) string {
+		template, prefixLen := "{line}:{column}: {message}", -1
+		for prefix, t := range templates {
+			if strings.HasPrefix(msg, prefix) && len(prefix) > prefixLen {
+				template, prefixLen = t, len(prefix)
+			}
+		}
+		return strings.NewReplacer(
+			"{line}", strconv.Itoa(location.Line),
+			"{column}", strconv.Itoa(location.Column),
+			"{message}", msg,
+		).Replace(template)
+	}
+}
+
+// parseErrorMessage returns the message of the error err of go/parser, rendered with the
+// ErrorMessage of cfg (if any).
+func parseErrorMessage(err error, cfg *config) string {
+	if cfg.errorMessage == nil {
+		return err.Error()
+	}
+	if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+		return cfg.errorMessage(Location{list[0].Pos.Line, list[0].Pos.Column}, list[0].Msg)
+	}
+	return cfg.errorMessage(Location{}, err.Error())
+}
+
+// errorMessage returns msg, the message of a parsing error of smgo at location, rendered with
+// the ErrorMessage of cfg (if any).
+func errorMessage(location Location, msg string, cfg *config) string {
+	if cfg.errorMessage == nil {
+		return msg
+	}
+	return cfg.errorMessage(location, msg)
+}
@@ -0,0 +1,38 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithErrorMessages(t *testing.T) {
+	t.Parallel()
+
+	messages := smgo.WithErrorMessages(smgo.TemplateMessages(map[string]string{
+		"expected":            "línea {line}, columna {column}: se esperaba otro símbolo",
+		"expected ';'":        "línea {line}, columna {column}: falta ';' ({message})",
+		"invalid UTF-8":       "codificación UTF-8 inválida en la línea {line}",
+		"unknown prefix, not": "never used",
+	}))
+	cases := []struct {
+		Src      string
+		Expected string
+		Location smgo.Location
+	}{
+		{"package messages\n\nfunc A( {\n}\n", "línea 3, columna 9: se esperaba otro símbolo", smgo.Location{1, 0}},
+		{"package messages\n\nvar A = 1 2\n", "línea 3, columna 11: falta ';' (expected ';', found 2)", smgo.Location{1, 0}},
+		{"package messages\n\n// \xff\n", "codificación UTF-8 inválida en la línea 3", smgo.Location{3, 4}},
+		{"package messages\n\nvar A = `\n", "3:9: raw string literal not terminated", smgo.Location{1, 0}},
+	}
+	for _, c := range cases {
+		file, err := smgo.Parse(bytes.NewReader([]byte(c.Src)), "UTF-8", messages)
+		require.Nil(t, err)
+		require.Len(t, file.ParsingErrors, 1, c.Src)
+		assert.Equal(t, c.Expected, file.ParsingErrors[0].Message)
+		assert.Equal(t, c.Location, file.ParsingErrors[0].Location)
+	}
+}
@@ -0,0 +1,111 @@
+package smgo
+
+import "go/ast"
+
+// WithMethodGrouping groups the methods following every top-level type declaration under the
+// type: the struct and interface containers get the methods as children after their members
+// (the closing brace of the members is then part of the span of the last one, or of the header
+// if there are none, and the footer is empty), and the other types are grouped with them in a
+// TypeNode container named like the type, whose header and footer are empty. Free-floating
+// comments between the methods are grouped too. The spans of a node can't be split, so the
+// methods declared before their type or after other declarations aren't grouped (see
+// NewPackageFile to gather all of them). Like declaration groups, the TypeNode containers are
+// transparent to the qualified names of their children.
+func WithMethodGrouping() Option {
+	return func(cfg *config) {
+		cfg.groupMethods = true
+	}
+}
+
+// groupMethods returns nodes with the type declarations followed by their methods grouped,
+// see WithMethodGrouping.
+func groupMethods(nodes []Node) []Node {
+	grouped := make([]Node, 0, len(nodes))
+	for i := 0; i < len(nodes); i++ {
+		name, ok := typeDeclaration(nodes[i])
+		if !ok {
+			grouped = append(grouped, nodes[i])
+			continue
+		}
+		last := i
+		for j := i + 1; j < len(nodes); j++ {
+			t, ok := nodes[j].(*Terminal)
+			if !ok || t.Type != Comment && (t.Type != FunctionNode || t.Receiver != name) {
+				break
+			}
+			if t.Type == FunctionNode {
+				last = j
+			}
+		}
+		if last == i {
+			grouped = append(grouped, nodes[i])
+			continue
+		}
+		start, _ := nodeRange(nodes[i])
+		_, end := nodeRange(nodes[last])
+		methods := nodes[i+1 : last+1]
+		if c, ok := nodes[i].(*Container); ok {
+			closeMembers(c)
+			c.LocationSpan.End = nodeLocationSpan(nodes[last]).End
+			c.FooterSpan = RuneSpan{end + 1, end}
+			c.Children = append(c.Children, methods...)
+			grouped = append(grouped, c)
+			i = last
+			continue
+		}
+		group := &Container{
+			Type:     TypeNode,
+			Name:     name,
+			Exported: ast.IsExported(name),
+			LocationSpan: LocationSpan{
+				Start: nodeLocationSpan(nodes[i]).Start,
+				End:   nodeLocationSpan(nodes[last]).End,
+			},
+			HeaderSpan: RuneSpan{start, start - 1},
+			FooterSpan: RuneSpan{end + 1, end},
+			Children:   append([]Node(nil), nodes[i:last+1]...),
+		}
+		grouped = append(grouped, group)
+		i = last
+	}
+	return grouped
+}
+
+// closeMembers moves the footer of c, the closing brace of its members, to the span of its last
+// member, or to its header if it has none.
+func closeMembers(c *Container) {
+	if len(c.Children) == 0 {
+		c.HeaderSpan.End = c.FooterSpan.End
+		return
+	}
+	switch n := c.Children[len(c.Children)-1].(type) {
+	case *Terminal:
+		n.Span.End = c.FooterSpan.End
+		n.LocationSpan.End = c.LocationSpan.End
+	case *Container:
+		n.FooterSpan.End = c.FooterSpan.End
+		n.LocationSpan.End = c.LocationSpan.End
+	}
+}
+
+// typeDeclaration returns the name of the type declared by node, without type parameters, if
+// it's a type declaration out of a group.
+func typeDeclaration(node Node) (string, bool) {
+	switch n := node.(type) {
+	case *Terminal:
+		return typeName(n.Name), n.Type == TypeNode
+	case *Container:
+		return typeName(n.Name), n.Type == StructNode || n.Type == InterfaceNode
+	}
+	return "", false
+}
+
+func nodeLocationSpan(node Node) LocationSpan {
+	switch n := node.(type) {
+	case *Terminal:
+		return n.LocationSpan
+	case *Container:
+		return n.LocationSpan
+	}
+	return LocationSpan{}
+}
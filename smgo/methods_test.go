@@ -0,0 +1,147 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithMethodGrouping(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package methods
+
+type Person struct {
+	Name string
+}
+
+func (p Person) Hi() string {
+	return "hi " + p.Name
+}
+
+// free-floating comment
+
+func (p *Person) SetName(name string) {
+	p.Name = name
+}
+
+type Celsius float64
+
+func F() {
+}
+
+func (c Celsius) String() string {
+	return ""
+}
+`)
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithMethodGrouping())
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	assert.Empty(t, file.Warnings)
+	require.Len(t, file.Children, 5)
+
+	// the methods are grouped under the struct, after the fields
+	person, ok := file.Children[1].(*smgo.Container)
+	require.True(t, ok)
+	assert.Equal(t, smgo.StructNode, person.Type)
+	assert.Equal(t, "Person", person.Name)
+	assert.True(t, person.Exported)
+	assert.Equal(t, newLocationSpan(2, 0, 15, 2), person.LocationSpan)
+	assert.Equal(t, smgo.RuneSpan{16, 37}, person.HeaderSpan)
+	assert.Equal(t, smgo.RuneSpan{193, 192}, person.FooterSpan)
+	require.Len(t, person.Children, 4)
+	name := person.Children[0].(*smgo.Terminal)
+	assert.Equal(t, "Name", name.Name)
+	assert.Equal(t, "\tName string\n}\n", string(src[name.Span.Start:name.Span.End+1]))
+	assert.Equal(t, newLocationSpan(4, 0, 5, 2), name.LocationSpan)
+	assert.Equal(t, "Hi", person.Children[1].(*smgo.Terminal).Name)
+	assert.Equal(t, smgo.Comment, person.Children[2].(*smgo.Terminal).Type)
+	assert.Equal(t, "SetName", person.Children[3].(*smgo.Terminal).Name)
+
+	// a method declared after another declaration isn't grouped
+	celsius := file.Children[2].(*smgo.Terminal)
+	assert.Equal(t, smgo.TypeNode, celsius.Type)
+	assert.Equal(t, "F", file.Children[3].(*smgo.Terminal).Name)
+	assert.Equal(t, "Celsius", file.Children[4].(*smgo.Terminal).Receiver)
+
+	// the methods are gathered under their types by NewPackageFile too
+	pkg := smgo.NewPackageFile(map[string]*smgo.File{"methods.go": file})
+	require.Len(t, pkg.Decls, 3)
+	assert.Len(t, pkg.Decls[0].Methods, 2)
+	assert.Len(t, pkg.Decls[1].Methods, 1)
+}
+
+func TestParseWithMethodGroupingInterfaceMembers(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package methods
+
+import "fmt"
+
+type Number interface {
+	~int | ~float64
+	fmt.Stringer
+	Sign() int
+}
+
+type Celsius float64
+
+func (c Celsius) Sign() int {
+	return 1
+}
+`)
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithMethodGrouping())
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	require.Len(t, file.Children, 4)
+
+	// embedded interfaces and type-set elements are named by their type expressions
+	number, ok := file.Children[2].(*smgo.Container)
+	require.True(t, ok)
+	assert.Equal(t, smgo.InterfaceNode, number.Type)
+	require.Len(t, number.Children, 3)
+	for i, name := range []string{"~int | ~float64", "fmt.Stringer", "Sign"} {
+		member := number.Children[i].(*smgo.Terminal)
+		assert.Equal(t, smgo.FieldNode, member.Type)
+		assert.Equal(t, name, member.Name)
+	}
+	assert.False(t, number.Children[0].(*smgo.Terminal).Exported)
+	assert.True(t, number.Children[1].(*smgo.Terminal).Exported)
+	assert.Equal(t, newLocationSpan(6, 0, 6, 17), number.Children[0].(*smgo.Terminal).LocationSpan)
+
+	celsius, ok := file.Children[3].(*smgo.Container)
+	require.True(t, ok)
+	assert.Equal(t, smgo.TypeNode, celsius.Type)
+	require.Len(t, celsius.Children, 2)
+	assert.Equal(t, "Sign", celsius.Children[1].(*smgo.Terminal).Name)
+}
+
+func TestParseWithMethodGroupingEmptyStruct(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package methods
+
+type Empty struct{}
+
+func (Empty) M() {}
+
+func (e *Empty) N() {}
+`)
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithMethodGrouping())
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	require.Len(t, file.Children, 2)
+
+	// without members, the closing brace is part of the header
+	empty, ok := file.Children[1].(*smgo.Container)
+	require.True(t, ok)
+	assert.Equal(t, smgo.StructNode, empty.Type)
+	assert.Equal(t, "\ntype Empty struct{}\n", string(src[empty.HeaderSpan.Start:empty.HeaderSpan.End+1]))
+	assert.Equal(t, newLocationSpan(2, 0, 7, 23), empty.LocationSpan)
+	require.Len(t, empty.Children, 2)
+	assert.Equal(t, "M", empty.Children[0].(*smgo.Terminal).Name)
+	assert.Equal(t, "N", empty.Children[1].(*smgo.Terminal).Name)
+}
@@ -0,0 +1,96 @@
+package smgo
+
+import (
+	"go/scanner"
+	"go/token"
+)
+
+// Metrics are size metrics of a declaration, computed from its span (for containers, from
+// the start of the header to the end of the footer).
+type Metrics struct {
+	// Lines is the number of lines with text other than whitespace.
+	Lines int
+	// CommentLines is the number of lines with comments.
+	CommentLines int
+	// Bytes is the length of the span.
+	Bytes int
+}
+
+// WithMetrics sets the Metrics of every node.
+func WithMetrics() Option {
+	return func(cfg *config) {
+		cfg.metrics = true
+	}
+}
+
+// setMetrics sets the Metrics of nodes, parsed from src, and their descendants.
+func setMetrics(nodes []Node, src []byte) {
+	inComment := commentBytes(src)
+	walkNodes(nodes, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			n.Metrics = spanMetrics(src, inComment, n.Span.Start, n.Span.End)
+		case *Container:
+			n.Metrics = spanMetrics(src, inComment, n.HeaderSpan.Start, n.FooterSpan.End)
+		}
+	})
+}
+
+// commentBytes reports whether every byte of src is part of a comment.
+func commentBytes(src []byte) []bool {
+	inComment := make([]bool, len(src))
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return inComment
+		}
+		if tok == token.COMMENT {
+			offset := fset.Position(pos).Offset
+			for i := offset; i < offset+len(lit) && i < len(src); i++ {
+				inComment[i] = true
+			}
+		}
+	}
+}
+
+// spanMetrics returns the metrics of the inclusive span [start, end] of src.
+func spanMetrics(src []byte, inComment []bool, start, end int) *Metrics {
+	if start < 0 {
+		start = 0
+	}
+	if end >= len(src) {
+		end = len(src) - 1
+	}
+	m := &Metrics{}
+	if end < start {
+		return m
+	}
+	m.Bytes = end - start + 1
+	text, comment := false, false
+	for i := start; i <= end; i++ {
+		switch {
+		case src[i] == '\n':
+			if text {
+				m.Lines++
+			}
+			if comment {
+				m.CommentLines++
+			}
+			text, comment = false, false
+		case inComment[i]:
+			text, comment = true, true
+		case src[i] != ' ' && src[i] != '\t' && src[i] != '\r':
+			text = true
+		}
+	}
+	if text {
+		m.Lines++
+	}
+	if comment {
+		m.CommentLines++
+	}
+	return m
+}
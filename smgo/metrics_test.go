@@ -0,0 +1,29 @@
+package smgo_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithMetrics(t *testing.T) {
+	t.Parallel()
+
+	src := "package metrics\n\n// A does a.\nfunc A() {\n\n\tb()\n}\n\n/*\nT is a type.\n*/\ntype T struct {\n\tX int // x\n}\n"
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithMetrics())
+	require.Nil(t, err)
+	require.Len(t, file.Children, 3)
+	assert.Equal(t, &smgo.Metrics{Lines: 1, CommentLines: 0, Bytes: 16}, file.Children[0].(*smgo.Terminal).Metrics)
+	assert.Equal(t, &smgo.Metrics{Lines: 4, CommentLines: 1, Bytes: 33}, file.Children[1].(*smgo.Terminal).Metrics)
+	typeT := file.Children[2].(*smgo.Container)
+	assert.Equal(t, &smgo.Metrics{Lines: 6, CommentLines: 4, Bytes: 50}, typeT.Metrics)
+	require.Len(t, typeT.Children, 1)
+	assert.Equal(t, &smgo.Metrics{Lines: 1, CommentLines: 1, Bytes: 12}, typeT.Children[0].(*smgo.Terminal).Metrics)
+
+	file, err = smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Nil(t, file.Children[0].(*smgo.Terminal).Metrics)
+}
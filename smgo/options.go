@@ -0,0 +1,132 @@
+package smgo
+
+import (
+	"go/token"
+	"unicode/utf8"
+)
+
+// Option configures how Parse builds the declarations tree.
+type Option func(*config)
+
+type config struct {
+	stableIDs   bool
+	lossy       bool
+	replacement rune
+	invalidUTF8 InvalidUTF8Policy
+	maxColumn   int
+	tabWidth    int
+	nfcNames    bool
+	complexity  bool
+	metrics     bool
+
+	transformers []Transformer
+	declHandlers []DeclHandler
+	strict       bool
+	errorMessage ErrorMessage
+	chunkSize    int
+	recordStats  bool
+	fileHeader   headerMode
+	groupMethods bool
+
+	warningHandler func(Warning)
+	encodedOffsets bool
+
+	// stats are measured even if they aren't recorded
+	stats Stats
+
+	// set by Batch
+	fileSet *token.FileSet
+	names   *nameTable
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		replacement: utf8.RuneError,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithStableIDs sets the ID of every declaration node, see NodeID.
+func WithStableIDs() Option {
+	return func(cfg *config) {
+		cfg.stableIDs = true
+	}
+}
+
+// WithLossyDecoding replaces the bytes that can't be decoded (or are invalid UTF-8) with
+// replacement, usually utf8.RuneError or '?', reporting every replacement as a warning. For
+// UTF-8 sources, it's the same as WithInvalidUTF8Policy(InvalidUTF8Replace). Note that
+// decoders of other encodings already produce utf8.RuneError for undecodable bytes; with
+// this option those are reported (and replaced) too. The spans and locations are offsets of
+// the source code before the replacements.
+func WithLossyDecoding(replacement rune) Option {
+	return func(cfg *config) {
+		cfg.lossy = true
+		cfg.replacement = replacement
+		cfg.invalidUTF8 = InvalidUTF8Replace
+	}
+}
+
+// WithEncodedOffsets makes the spans and the byte columns of the locations of sources in an
+// encoding other than UTF-8 refer to the original encoded bytes (the BOM included, which
+// counts in the columns of the first line), instead of the decoded UTF-8 bytes. Visual
+// columns (see WithTabWidth) don't depend on the encoding.
+func WithEncodedOffsets() Option {
+	return func(cfg *config) {
+		cfg.encodedOffsets = true
+	}
+}
+
+// InvalidUTF8Policy is the handling of invalid UTF-8 sequences in UTF-8 sources.
+type InvalidUTF8Policy int
+
+//go:generate stringer -type=InvalidUTF8Policy
+
+const (
+	// InvalidUTF8Error reports the first invalid sequence as a parsing error.
+	InvalidUTF8Error InvalidUTF8Policy = iota
+	// InvalidUTF8Replace replaces invalid sequences with utf8.RuneError (or the replacement
+	// of WithLossyDecoding), reporting them as warnings.
+	InvalidUTF8Replace
+	// InvalidUTF8PassThrough accepts invalid sequences in comments and string literals
+	// without reporting them, keeping spans relative to the original bytes.
+	InvalidUTF8PassThrough
+)
+
+// WithInvalidUTF8Policy sets the handling of invalid UTF-8 sequences in UTF-8 sources
+// (InvalidUTF8Error by default).
+func WithInvalidUTF8Policy(policy InvalidUTF8Policy) Option {
+	return func(cfg *config) {
+		cfg.invalidUTF8 = policy
+	}
+}
+
+// WithMaxColumn caps the columns reported in locations to max, reporting a warning for every
+// line with capped columns, e.g. to math.MaxInt32 for clients storing the columns in 32-bit
+// integers. The columns aren't capped by default.
+func WithMaxColumn(max int) Option {
+	return func(cfg *config) {
+		cfg.maxColumn = max
+	}
+}
+
+// WithTabWidth reports visual columns in locations, counting characters and expanding tabs to
+// the next multiple of tabWidth. Spans are still byte offsets. By default, columns count
+// bytes.
+func WithTabWidth(tabWidth int) Option {
+	return func(cfg *config) {
+		cfg.tabWidth = tabWidth
+	}
+}
+
+// WithNFCNames normalizes the names of the nodes to the Unicode Normalization Form C, so
+// identifiers written with combining characters get the same name (and ID) whatever form
+// they were saved in.
+func WithNFCNames() Option {
+	return func(cfg *config) {
+		cfg.nfcNames = true
+	}
+}
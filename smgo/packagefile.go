@@ -0,0 +1,97 @@
+package smgo
+
+import "sort"
+
+// PackageFile is the declarations tree of a package, merging the declarations trees of its
+// files: the top-level declarations of every file (but its package clause, imports and
+// free-floating comments), with the declarations of groups listed one by one, and the
+// methods of the types declared in the package gathered under their types (the other methods
+// are listed after every other declaration).
+type PackageFile struct {
+	// Name is the name of the package, from the package clause of the first file.
+	Name  string
+	Decls []*PackageDecl
+}
+
+// PackageDecl is a declaration of a package.
+type PackageDecl struct {
+	// Path is the path of the file declaring Node.
+	Path string
+	Node Node
+	// Methods are the methods of a type, from any file of the package, in order of path.
+	Methods []*PackageDecl
+}
+
+// NewPackageFile merges files, the declarations trees of the files of a package by path,
+// into a PackageFile. Files are merged in order of path.
+func NewPackageFile(files map[string]*File) *PackageFile {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	pkg := &PackageFile{}
+	types := make(map[string]*PackageDecl)
+	var methods []*PackageDecl
+	var add func(path string, nodes []Node)
+	add = func(path string, nodes []Node) {
+		for _, node := range nodes {
+			decl := &PackageDecl{
+				Path: path,
+				Node: node,
+			}
+			switch n := node.(type) {
+			case *Terminal:
+				switch {
+				case n.Type == PackageNode:
+					if pkg.Name == "" {
+						pkg.Name = n.Name
+					}
+					continue
+				case n.Type == ImportNode || n.Type == Comment:
+					continue
+				case n.Type == TypeNode:
+					types[typeName(n.Name)] = decl
+				case n.Receiver != "":
+					methods = append(methods, decl)
+					continue
+				}
+			case *Container:
+				switch n.Type {
+				case PackageNode:
+					// header container
+					if pkg.Name == "" {
+						pkg.Name = n.Name
+					}
+					continue
+				case ImportNode:
+					continue
+				case ConstNode, VarNode, TypeNode:
+					// declaration group
+					add(path, n.Children)
+					continue
+				}
+				types[typeName(n.Name)] = decl
+				// the methods grouped under their type (see WithMethodGrouping)
+				for _, child := range n.Children {
+					if t, ok := child.(*Terminal); ok && t.Receiver != "" {
+						methods = append(methods, &PackageDecl{Path: path, Node: t})
+					}
+				}
+			}
+			pkg.Decls = append(pkg.Decls, decl)
+		}
+	}
+	for _, path := range paths {
+		add(path, files[path].Children)
+	}
+	for _, method := range methods {
+		if t, ok := types[method.Node.(*Terminal).Receiver]; ok {
+			t.Methods = append(t.Methods, method)
+			continue
+		}
+		pkg.Decls = append(pkg.Decls, method)
+	}
+	return pkg
+}
@@ -0,0 +1,56 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPackageFile(t *testing.T) {
+	t.Parallel()
+
+	srcs := map[string]string{
+		"b.go": "package pkg\n\nimport \"fmt\"\n\nfunc (p *Person) SayHi() {\n\tfmt.Println(p.Name)\n}\n\nfunc (o Other) M() {\n}\n",
+		"a.go": "package pkg\n\n// Person is a person.\ntype Person struct {\n\tName string\n}\n\nfunc (p Person) String() string {\n\treturn p.Name\n}\n\nconst (\n\tA = 1\n\tB = 2\n)\n\ntype (\n\tID int\n)\n",
+		"c.go": "package pkg\n\n// free-floating comment\n\nfunc (id ID) Valid() bool {\n\treturn id > 0\n}\n",
+	}
+	files := make(map[string]*smgo.File)
+	for path, src := range srcs {
+		file, err := smgo.Parse(bytes.NewReader([]byte(src)), "UTF-8")
+		require.Nil(t, err)
+		files[path] = file
+	}
+	pkg := smgo.NewPackageFile(files)
+	assert.Equal(t, "pkg", pkg.Name)
+
+	type decl struct {
+		Path, Name string
+		Methods    []string
+	}
+	var decls []decl
+	for _, d := range pkg.Decls {
+		actual := decl{Path: d.Path}
+		switch n := d.Node.(type) {
+		case *smgo.Terminal:
+			actual.Name = n.Name
+		case *smgo.Container:
+			actual.Name = n.Name
+		}
+		for _, m := range d.Methods {
+			method := m.Node.(*smgo.Terminal)
+			actual.Methods = append(actual.Methods, m.Path+":"+method.Name)
+			assert.Contains(t, srcs[m.Path][method.Span.Start:method.Span.End+1], ") "+method.Name+"(")
+		}
+		decls = append(decls, actual)
+	}
+	assert.Equal(t, []decl{
+		{"a.go", "Person", []string{"a.go:String", "b.go:SayHi"}},
+		{"a.go", "A", nil},
+		{"a.go", "B", nil},
+		{"a.go", "ID", []string{"c.go:Valid"}},
+		{"b.go", "M", nil},
+	}, decls)
+}
@@ -1,64 +1,178 @@
 package smgo
 
 import (
+	"bytes"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
 	"io/ioutil"
 	"sort"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
-	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
 )
 
 var ErrUnsupportedEncoding = errors.New("Unsupported encoding")
 
 // Parse parses the GO source code from src and returns a *smgo.File declarations tree.
-func Parse(src io.Reader, encoding string) (*File, error) {
-	encoding = strings.ToUpper(encoding)
-	switch encoding {
-	case "UTF-8":
-	case "WINDOWS-1252":
-		decoder := charmap.Windows1252.NewDecoder()
-		src = decoder.Reader(src)
-	default:
-		return nil, ErrUnsupportedEncoding
+func Parse(src io.Reader, encoding string, opts ...Option) (*File, error) {
+	_, err := lookupEncoding(encoding)
+	if err != nil {
+		return nil, err
 	}
-
 	srcBytes, err := ioutil.ReadAll(src)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error reading src")
 	}
+	return ParseBytes(srcBytes, encoding, opts...)
+}
 
-	fset := token.NewFileSet()
-	fileAST, err := parser.ParseFile(fset, "", srcBytes, parser.ParseComments)
+// ParseBytes is Parse for source code already in memory. src is neither modified nor
+// retained: the declarations tree doesn't reference it after ParseBytes returns, so src can
+// be a pooled or memory-mapped buffer, reused or released right away.
+func ParseBytes(src []byte, encoding string, opts ...Option) (*File, error) {
+	start := time.Now()
+	cfg := newConfig(opts)
+
+	enc, err := lookupEncoding(encoding)
 	if err != nil {
-		file := &File{
-			LocationSpan: LocationSpan{
-				Start: Location{1, 0},
-				End:   Location{1, 0},
-			},
-			FooterSpan: RuneSpan{0, -1},
-			ParsingErrors: []*ParsingError{
-				{
-					Location: Location{1, 0},
-					Message:  err.Error(),
-				},
-			},
+		return nil, err
+	}
+	srcBytes, parsedBytes, replaced, warnings, err := decode(src, enc, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.stats.Decode = time.Since(start)
+
+	var maps []*offsetMap
+	if len(cfg.transformers) > 0 {
+		preprocessStart := time.Now()
+		var preprocessWarnings []*Warning
+		parsedBytes, maps, preprocessWarnings = preprocess(parsedBytes, cfg.transformers)
+		warnings = append(warnings, preprocessWarnings...)
+		cfg.stats.Preprocess = time.Since(preprocessStart)
+	}
+	// the replacements of invalid input are mapped back like the transformations
+	if replaced != nil {
+		maps = append(maps, replaced)
+	}
+	var file *File
+	if cfg.chunkSize > 0 && len(parsedBytes) > cfg.chunkSize {
+		file, err = parseChunked(parsedBytes, enc == nil, cfg)
+	} else {
+		file, err = parseSrc(parsedBytes, enc == nil, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(maps) > 0 {
+		remapFile(file, parsedBytes, srcBytes, maps)
+	}
+	file.InvalidUTF8Policy = cfg.invalidUTF8
+	file.LineEndings, file.FirstMixedLine = auditLineEndings(srcBytes)
+	file.Warnings = warnings
+	if cfg.nfcNames {
+		normalizeNames(file.Children)
+	}
+	if cfg.names != nil {
+		cfg.names.intern(file.Children)
+	}
+	setFileHeader(file, cfg.fileHeader)
+	if cfg.groupMethods {
+		file.Children = groupMethods(file.Children)
+	}
+	file.Warnings = append(file.Warnings, duplicateWarnings(file, srcBytes)...)
+	file.Warnings = append(file.Warnings, iotaWarnings(file, srcBytes)...)
+	if cfg.metrics {
+		setMetrics(file.Children, srcBytes)
+	}
+	if cfg.tabWidth > 0 {
+		expandTabs(file, srcBytes, cfg.tabWidth)
+	}
+	if enc != nil && cfg.encodedOffsets {
+		err = encodeOffsets(file, src, srcBytes, enc, cfg.tabWidth == 0)
+		if err != nil {
+			return nil, err
 		}
-		return file, nil
 	}
+	file.Warnings = append(file.Warnings, capColumns(file, cfg.maxColumn)...)
+	if cfg.strict {
+		srcLen := len(srcBytes)
+		if enc != nil && cfg.encodedOffsets {
+			srcLen = len(src)
+		}
+		err = checkSpec(file, srcLen)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.recordStats {
+		cfg.stats.Total = time.Since(start)
+		file.Stats = &cfg.stats
+	}
+	reportWarnings(file, cfg)
+	return file, nil
+}
+
+// normalizeNames normalizes the names of nodes and their descendants to NFC.
+func normalizeNames(nodes []Node) {
+	walkNodes(nodes, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			n.Name = norm.NFC.String(n.Name)
+		case *Container:
+			n.Name = norm.NFC.String(n.Name)
+		}
+	})
+}
+
+// parseSrc builds the declarations tree of the decoded source code src. isUTF8 reports
+// whether src wasn't transcoded.
+func parseSrc(srcBytes []byte, isUTF8 bool, cfg *config) (*File, error) {
+	if isUTF8 && cfg.invalidUTF8 == InvalidUTF8Error {
+		location, invalid := firstInvalidUTF8(srcBytes)
+		if invalid {
+			return newErrorFile(location, errorMessage(location, "invalid UTF-8 encoding", cfg)), nil
+		}
+	}
+
+	fset := cfg.fileSet
+	if fset == nil {
+		fset = token.NewFileSet()
+	}
+	start := time.Now()
+	fileAST, err := parser.ParseFile(fset, "", srcBytes, parser.ParseComments)
+	cfg.stats.Parse += time.Since(start)
+	if fileAST == nil {
+		return newErrorFile(Location{1, 0}, parseErrorMessage(err, cfg)), nil
+	}
+	tokenFile := fset.File(fileAST.FileStart)
+	if cfg.fileSet != nil {
+		// a shared FileSet doesn't keep the positions of the parsed files
+		defer fset.RemoveFile(tokenFile)
+	}
+	if err != nil {
+		return newErrorFile(Location{1, 0}, parseErrorMessage(err, cfg)), nil
+	}
+	base := tokenFile.Base()
 
 	// visit top-level declarations only
-	v := newVisitor(fset, fileAST)
+	start = time.Now()
+	v := newVisitor(fset, fileAST, cfg)
 	for _, decl := range fileAST.Decls {
-		ast.Walk(v, decl)
+		if !v.handleDecl(decl) {
+			ast.Walk(v, decl)
+		}
 	}
 	// fix file LocationSpan
-	pos := v.FileSet.Position(token.Pos(1))
-	end := v.FileSet.Position(token.Pos(len(srcBytes)))
+	pos := v.FileSet.Position(token.Pos(base))
+	end := v.FileSet.Position(token.Pos(base + len(srcBytes) - 1))
 	v.File.LocationSpan = LocationSpan{
 		Start: Location{
 			Line:   pos.Line,
@@ -70,20 +184,109 @@ func Parse(src io.Reader, encoding string) (*File, error) {
 		},
 	}
 
-	ffc := v.freeFloatingCommentsBefore(len(srcBytes))
+	// including the comment ending the source code, if any
+	ffc := v.freeFloatingCommentsBefore(len(srcBytes) + 1)
+	if trimmed := len(bytes.TrimRightFunc(srcBytes, unicode.IsSpace)); trimmed > 0 {
+		v.lastLine = v.FileSet.Position(token.Pos(base + trimmed - 1)).Line
+	}
 	v.AddFFCToParentContainer(ffc...)
 	//for _, c := range ffc {
 	//	v.AddToParentContainer(c)
 	//}
 
-	err = fixBlockBoundaries(fset, v.File, srcBytes)
+	cfg.stats.Visit += time.Since(start)
+
+	start = time.Now()
+	err = fixBlockBoundaries(fset, base, v.File, srcBytes)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error reading fixing boundaries")
 	}
-
+	cfg.stats.Fix += time.Since(start)
+	setExported(v.File.Children)
 	return v.File, nil
 }
 
+// isDeprecated reports whether a paragraph of any of docs starts with "Deprecated: ", the
+// convention to mark deprecated identifiers.
+func isDeprecated(docs ...*ast.CommentGroup) bool {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, paragraph := range strings.Split(doc.Text(), "\n\n") {
+			if strings.HasPrefix(paragraph, "Deprecated: ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeParamsName returns name followed by the names of the type parameters in params, if
+// any (like "Map[K, V]"), so generic declarations are told apart by their names.
+func typeParamsName(name string, params *ast.FieldList) string {
+	if params == nil || len(params.List) == 0 {
+		return name
+	}
+	var names []string
+	for _, field := range params.List {
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+	}
+	return name + "[" + strings.Join(names, ", ") + "]"
+}
+
+// typeName returns name without its type parameters, the name of the receivers of the
+// methods of the type.
+func typeName(name string) string {
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// identName returns the identifier declared by a node named name: the unqualified type name
+// of an embedded field (like "Reader" for "*io.Reader").
+func identName(name string) string {
+	name = strings.TrimLeft(name, "*")
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return typeName(name)
+}
+
+// setExported sets the Exported field of nodes and their descendants. The names of package
+// clauses, imports and comments aren't exported identifiers, and the names of declaration
+// groups ("const", "var"...) aren't exported.
+func setExported(nodes []Node) {
+	walkNodes(nodes, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			n.Exported = n.Type != PackageNode && n.Type != ImportNode && n.Type != Comment && ast.IsExported(identName(n.Name))
+		case *Container:
+			n.Exported = n.Type != ImportNode && ast.IsExported(n.Name)
+		}
+	})
+}
+
+// newErrorFile returns the File of a source code that can't be parsed.
+func newErrorFile(location Location, message string) *File {
+	return &File{
+		LocationSpan: LocationSpan{
+			Start: Location{1, 0},
+			End:   Location{1, 0},
+		},
+		FooterSpan: RuneSpan{0, -1},
+		ParsingErrors: []*ParsingError{
+			{
+				Location: location,
+				Message:  message,
+			},
+		},
+	}
+}
+
 type parentNode interface {
 	AddNode(node Node)
 	Nodes() []Node
@@ -92,15 +295,21 @@ type parentNode interface {
 type commentSet map[*ast.CommentGroup]struct{}
 
 type visitor struct {
+	Config         *config
 	FileSet        *token.FileSet
 	File           *File
 	Comments       commentSet
+	commentGroups  []*ast.CommentGroup
+	nextComment    int
 	astStack       []ast.Node
 	containerStack []parentNode
+	// lastLine is the last line of the source code with other than whitespace, once visited
+	lastLine int
 }
 
-func newVisitor(fset *token.FileSet, srcAST *ast.File) *visitor {
+func newVisitor(fset *token.FileSet, srcAST *ast.File, cfg *config) *visitor {
 	v := &visitor{
+		Config:  cfg,
 		FileSet: fset,
 	}
 	// save comments to insert free-floating comments in the resulting File as Comment nodes.
@@ -108,6 +317,7 @@ func newVisitor(fset *token.FileSet, srcAST *ast.File) *visitor {
 	for _, cg := range srcAST.Comments {
 		v.Comments[cg] = struct{}{}
 	}
+	v.commentGroups = srcAST.Comments
 
 	file := v.createFile(srcAST)
 	v.File = file
@@ -186,9 +396,9 @@ func (v *visitor) AddFFCToParentContainer(ffc ...*Terminal) {
 					}
 				}
 			}
-			// merge last ffc to file footer
+			// merge last ffc to file footer, if only whitespace follows it
 			lastFFC := ffc[len(ffc)-1]
-			if lastFFC.LocationSpan.End.Line == pc.LocationSpan.End.Line {
+			if lastFFC.LocationSpan.End.Line == pc.LocationSpan.End.Line || lastFFC.LocationSpan.End.Line == v.lastLine {
 				pc.FooterSpan.Start = lastFFC.Span.Start
 				ffc = ffc[:len(ffc)-1]
 			}
@@ -282,6 +492,7 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 					panic("*ast.ValueSpec expected")
 				}
 				importNode := v.createImport(n, is)
+				v.setID(importNode, "", is)
 				ffc := v.freeFloatingCommentsBefore(importNode.Span.Start)
 				v.AddFFCToParentContainer(ffc...)
 				v.AddToParentContainer(importNode)
@@ -291,6 +502,7 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 					panic("*ast.ValueSpec expected")
 				}
 				constNode := v.createConst(n, vs)
+				v.setID(constNode, "", vs.Type)
 				ffc := v.freeFloatingCommentsBefore(constNode.Span.Start)
 				v.AddFFCToParentContainer(ffc...)
 				v.AddToParentContainer(constNode)
@@ -304,6 +516,7 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 					panic("*ast.ValueSpec expected")
 				}
 				varNode := v.createVar(n, vs)
+				v.setID(varNode, "", vs.Type)
 				ffc := v.freeFloatingCommentsBefore(varNode.Span.Start)
 				v.AddFFCToParentContainer(ffc...)
 				v.AddToParentContainer(varNode)
@@ -319,11 +532,13 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 		switch gd.Tok {
 		case token.CONST:
 			constNode := v.createConstInGroup(n)
+			v.setID(constNode, "", n.Type)
 			ffc := v.freeFloatingCommentsBefore(constNode.Span.Start)
 			v.AddFFCToParentContainer(ffc...)
 			parentContainer.AddNode(constNode)
 		case token.VAR:
 			varNode := v.createVarInGroup(n)
+			v.setID(varNode, "", n.Type)
 			ffc := v.freeFloatingCommentsBefore(varNode.Span.Start)
 			v.AddFFCToParentContainer(ffc...)
 			parentContainer.AddNode(varNode)
@@ -331,12 +546,14 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 		return nil
 	case *ast.ImportSpec:
 		importNode := v.createImportInGroup(n)
+		v.setID(importNode, "", n)
 		ffc := v.freeFloatingCommentsBefore(importNode.Span.Start)
 		v.AddFFCToParentContainer(ffc...)
 		v.AddToParentContainer(importNode)
 		return nil
 	case *ast.FuncDecl:
 		funcNode := v.createFunc(n)
+		v.setID(funcNode, receiverName(n), n.Type)
 		ffc := v.freeFloatingCommentsBefore(funcNode.Span.Start)
 		v.AddFFCToParentContainer(ffc...)
 		v.AddToParentContainer(funcNode)
@@ -347,7 +564,12 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 		if !ok {
 			panic("*ast.GenDecl expected")
 		}
-		switch n.Type.(type) {
+		typ := n.Type
+		if n.Assign.IsValid() {
+			// an alias doesn't declare the fields or methods of the aliased type
+			typ = nil
+		}
+		switch typ.(type) {
 		case *ast.InterfaceType:
 			var container *Container
 			if gd.Lparen.IsValid() {
@@ -355,6 +577,7 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 			} else {
 				container = v.createInterface(gd, n)
 			}
+			v.setID(container, "", nil)
 			ffc := v.freeFloatingCommentsBefore(container.HeaderSpan.Start)
 			if len(ffc) > 0 {
 
@@ -370,6 +593,7 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 			} else {
 				container = v.createStruct(gd, n)
 			}
+			v.setID(container, "", nil)
 			ffc := v.freeFloatingCommentsBefore(container.HeaderSpan.Start)
 			v.AddFFCToParentContainer(ffc...)
 			v.AddToParentContainer(container)
@@ -382,6 +606,7 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 			} else {
 				terminal = v.createType(gd, n)
 			}
+			v.setID(terminal, "", n.Type)
 			ffc := v.freeFloatingCommentsBefore(terminal.Span.Start)
 			v.AddFFCToParentContainer(ffc...)
 			v.AddToParentContainer(terminal)
@@ -389,10 +614,19 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 		}
 	case *ast.Field:
 		fieldNode := v.createField(n)
+		v.setID(fieldNode, "", n.Type)
 		ffc := v.freeFloatingCommentsBefore(fieldNode.Span.Start)
 		v.AddFFCToParentContainer(ffc...)
 		v.AddToParentContainer(fieldNode)
 		return nil
+	case *ast.FieldList:
+		parentASTNode, container := v.Peek()
+		if _, ok := parentASTNode.(*ast.TypeSpec); ok {
+			// the type parameters of a type aren't fields
+			return nil
+		}
+		v.Push(n, container)
+		return v
 	default:
 		_, container := v.Peek()
 		v.Push(n, container)
@@ -400,27 +634,61 @@ func (v *visitor) Visit(node ast.Node) ast.Visitor {
 	}
 }
 
+// deleteComments deletes the comments in [pos, end] from the free-floating comments.
+func (v *visitor) deleteComments(pos, end token.Pos) {
+	for i := v.commentAt(pos); i < len(v.commentGroups) && v.commentGroups[i].Pos() < end; i++ {
+		if cg := v.commentGroups[i]; cg.End() <= end {
+			delete(v.Comments, cg)
+		}
+	}
+}
+
+// trailingComment returns the free-floating comment after end in the line of end, if any.
+func (v *visitor) trailingComment(end token.Pos) *ast.CommentGroup {
+	endLine := v.FileSet.Position(end).Line
+	for i := v.commentAt(end); i < len(v.commentGroups); i++ {
+		cg := v.commentGroups[i]
+		if v.FileSet.Position(cg.Pos()).Line != endLine {
+			break
+		}
+		if _, ok := v.Comments[cg]; ok {
+			return cg
+		}
+	}
+	return nil
+}
+
+// commentAt returns the index of the first comment group starting at pos or after it. The
+// comment groups are sorted by position, so it's a binary search.
+func (v *visitor) commentAt(pos token.Pos) int {
+	return sort.Search(len(v.commentGroups), func(i int) bool {
+		return v.commentGroups[i].Pos() >= pos
+	})
+}
+
+// freeFloatingCommentsBefore returns the comments ending before offset not attached to any
+// declaration, as Comment nodes. The comment groups are sorted by position and offsets are
+// visited in increasing order, so every comment group is checked once.
 func (v *visitor) freeFloatingCommentsBefore(offset int) []*Terminal {
 	cgNodes := make([]*ast.CommentGroup, 0, 5)
-	for cg, _ := range v.Comments {
-		cgPosition := v.FileSet.Position(cg.End())
-		if cgPosition.Offset < offset {
+	for ; v.nextComment < len(v.commentGroups); v.nextComment++ {
+		cg := v.commentGroups[v.nextComment]
+		if v.FileSet.Position(cg.End()).Offset >= offset {
+			break
+		}
+		if _, ok := v.Comments[cg]; ok {
 			cgNodes = append(cgNodes, cg)
 		}
 	}
-	if len(cgNodes) > 1 {
-		sort.Slice(cgNodes, func(i, j int) bool {
-			posi := v.FileSet.Position(cgNodes[i].Pos())
-			posj := v.FileSet.Position(cgNodes[j].Pos())
-			return posi.Offset < posj.Offset
-		})
-	}
 	comments := make([]*Terminal, 0, len(cgNodes))
 	for _, cg := range cgNodes {
 		delete(v.Comments, cg)
 		name := strings.TrimSpace(cg.Text())
-		if len(name) > 10 {
-			name = name[0:10] + "..."
+		if !utf8.ValidString(name) {
+			name = strings.ToValidUTF8(name, string(utf8.RuneError))
+		}
+		if utf8.RuneCountInString(name) > 10 {
+			name = string([]rune(name)[0:10]) + "..."
 		}
 		comments = append(comments, &Terminal{
 			Type:         Comment,
@@ -451,12 +719,15 @@ func (v *visitor) createFile(n *ast.File) *File {
 		f.AddNode(c)
 	}
 	end := n.Name.End()
-	f.AddNode(&Terminal{
+	pkg := &Terminal{
 		Type:         PackageNode,
 		Name:         n.Name.Name,
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
-	})
+	}
+	v.setID(pkg, "", nil)
+	f.AddNode(pkg)
 	return f
 }
 
@@ -469,6 +740,8 @@ func (v *visitor) createConst(gd *ast.GenDecl, n *ast.ValueSpec) *Terminal {
 	}
 	pos := gd.Pos()
 	end := gd.End()
+	// the comments in the values aren't free-floating comments
+	v.deleteComments(n.Pos(), n.End())
 	if n.Comment != nil {
 		end = n.Comment.End()
 		delete(v.Comments, n.Comment)
@@ -476,6 +749,7 @@ func (v *visitor) createConst(gd *ast.GenDecl, n *ast.ValueSpec) *Terminal {
 	return &Terminal{
 		Type:         ConstNode,
 		Name:         n.Names[0].Name,
+		Deprecated:   isDeprecated(gd.Doc, n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
 	}
@@ -488,6 +762,7 @@ func (v *visitor) createConstGroup(n *ast.GenDecl) *Container {
 	c := &Container{
 		Type:         ConstNode,
 		Name:         "const",
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromNode(v.FileSet, n),
 		HeaderSpan:   runeSpanFromPositions(v.FileSet, n.Pos(), n.Lparen),
 		FooterSpan:   runeSpanFromPositions(v.FileSet, n.Rparen, n.End()),
@@ -504,6 +779,8 @@ func (v *visitor) createConstInGroup(n *ast.ValueSpec) *Terminal {
 	}
 	pos := n.Pos()
 	end := n.End()
+	// the comments in the values aren't free-floating comments
+	v.deleteComments(n.Pos(), n.End())
 	if n.Comment != nil {
 		end = n.Comment.End()
 		delete(v.Comments, n.Comment)
@@ -511,6 +788,7 @@ func (v *visitor) createConstInGroup(n *ast.ValueSpec) *Terminal {
 	return &Terminal{
 		Type:         ConstNode,
 		Name:         n.Names[0].Name,
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
 	}
@@ -520,12 +798,26 @@ func (v *visitor) createFunc(n *ast.FuncDecl) *Terminal {
 	if n.Doc != nil {
 		delete(v.Comments, n.Doc)
 	}
-	return &Terminal{
+	// the comments in the body aren't free-floating comments, and a comment after the
+	// function, in its last line, is part of it
+	pos, end := n.Pos(), n.End()
+	v.deleteComments(pos, end)
+	if cg := v.trailingComment(end); cg != nil {
+		end = cg.End()
+		delete(v.Comments, cg)
+	}
+	t := &Terminal{
 		Type:         FunctionNode,
-		Name:         n.Name.Name,
-		LocationSpan: locationSpanFromNode(v.FileSet, n),
-		Span:         runeSpanFromNode(v.FileSet, n),
+		Name:         typeParamsName(n.Name.Name, n.Type.TypeParams),
+		Deprecated:   isDeprecated(n.Doc),
+		Receiver:     receiverName(n),
+		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
+		Span:         runeSpanFromPositions(v.FileSet, pos, end),
+	}
+	if v.Config.complexity {
+		t.Complexity = complexity(n)
 	}
+	return t
 }
 
 func (v *visitor) createImport(gd *ast.GenDecl, n *ast.ImportSpec) *Terminal {
@@ -617,7 +909,8 @@ func (v *visitor) createInterface(genDecl *ast.GenDecl, typeSpec *ast.TypeSpec)
 	}
 	container := &Container{
 		Type:         InterfaceNode,
-		Name:         typeSpec.Name.Name,
+		Name:         typeParamsName(typeSpec.Name.Name, typeSpec.TypeParams),
+		Deprecated:   isDeprecated(genDecl.Doc, typeSpec.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		HeaderSpan:   runeSpanFromPositions(v.FileSet, pos, st.Methods.Opening),
 		FooterSpan:   runeSpanFromPositions(v.FileSet, st.Methods.Closing, end),
@@ -644,7 +937,8 @@ func (v *visitor) createInterfaceInGroup(typeSpec *ast.TypeSpec) *Container {
 	}
 	container := &Container{
 		Type:         InterfaceNode,
-		Name:         typeSpec.Name.Name,
+		Name:         typeParamsName(typeSpec.Name.Name, typeSpec.TypeParams),
+		Deprecated:   isDeprecated(typeSpec.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		HeaderSpan:   runeSpanFromPositions(v.FileSet, pos, st.Methods.Opening),
 		FooterSpan:   runeSpanFromPositions(v.FileSet, st.Methods.Closing, end),
@@ -674,7 +968,8 @@ func (v *visitor) createStruct(genDecl *ast.GenDecl, typeSpec *ast.TypeSpec) *Co
 	}
 	container := &Container{
 		Type:         StructNode,
-		Name:         typeSpec.Name.Name,
+		Name:         typeParamsName(typeSpec.Name.Name, typeSpec.TypeParams),
+		Deprecated:   isDeprecated(genDecl.Doc, typeSpec.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		HeaderSpan:   runeSpanFromPositions(v.FileSet, pos, st.Fields.Opening),
 		FooterSpan:   runeSpanFromPositions(v.FileSet, st.Fields.Closing, end),
@@ -701,7 +996,8 @@ func (v *visitor) createStructInGroup(typeSpec *ast.TypeSpec) *Container {
 	}
 	container := &Container{
 		Type:         StructNode,
-		Name:         typeSpec.Name.Name,
+		Name:         typeParamsName(typeSpec.Name.Name, typeSpec.TypeParams),
+		Deprecated:   isDeprecated(typeSpec.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		HeaderSpan:   runeSpanFromPositions(v.FileSet, pos, st.Fields.Opening),
 		FooterSpan:   runeSpanFromPositions(v.FileSet, st.Fields.Closing, end),
@@ -718,18 +1014,30 @@ func (v *visitor) createField(n *ast.Field) *Terminal {
 	}
 	pos := n.Pos()
 	end := n.End()
+	// the comments in the type aren't free-floating comments
+	v.deleteComments(pos, end)
 	if n.Comment != nil {
 		end = n.Comment.End()
 		delete(v.Comments, n.Comment)
 	}
 	return &Terminal{
 		Type:         FieldNode,
-		Name:         n.Names[0].Name,
+		Name:         fieldName(n),
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
 	}
 }
 
+// fieldName returns the name of n, or its type expression (like "io.Reader" or "*Person") if
+// it's an embedded field.
+func fieldName(n *ast.Field) string {
+	if len(n.Names) == 0 {
+		return types.ExprString(n.Type)
+	}
+	return n.Names[0].Name
+}
+
 func (v *visitor) createType(genDecl *ast.GenDecl, n *ast.TypeSpec) *Terminal {
 	if genDecl.Doc != nil {
 		delete(v.Comments, genDecl.Doc)
@@ -739,13 +1047,17 @@ func (v *visitor) createType(genDecl *ast.GenDecl, n *ast.TypeSpec) *Terminal {
 	}
 	pos := genDecl.Pos()
 	end := genDecl.End()
+	// the comments in the type aren't free-floating comments
+	v.deleteComments(n.Pos(), n.End())
 	if n.Comment != nil {
 		end = n.Comment.End()
 		delete(v.Comments, n.Comment)
 	}
 	return &Terminal{
 		Type:         TypeNode,
-		Name:         n.Name.Name,
+		Name:         typeParamsName(n.Name.Name, n.TypeParams),
+		Alias:        n.Assign.IsValid(),
+		Deprecated:   isDeprecated(genDecl.Doc, n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
 	}
@@ -758,6 +1070,7 @@ func (v *visitor) createTypeGroup(n *ast.GenDecl) *Container {
 	c := &Container{
 		Type:         TypeNode,
 		Name:         "type",
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromNode(v.FileSet, n),
 		HeaderSpan:   runeSpanFromPositions(v.FileSet, n.Pos(), n.Lparen),
 		FooterSpan:   runeSpanFromPositions(v.FileSet, n.Rparen, n.End()),
@@ -774,13 +1087,17 @@ func (v *visitor) createTypeInGroup(n *ast.TypeSpec) *Terminal {
 	}
 	pos := n.Pos()
 	end := n.End()
+	// the comments in the type aren't free-floating comments
+	v.deleteComments(pos, end)
 	if n.Comment != nil {
 		end = n.Comment.End()
 		delete(v.Comments, n.Comment)
 	}
 	return &Terminal{
 		Type:         TypeNode,
-		Name:         n.Name.Name,
+		Name:         typeParamsName(n.Name.Name, n.TypeParams),
+		Alias:        n.Assign.IsValid(),
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
 	}
@@ -795,6 +1112,8 @@ func (v *visitor) createVar(gd *ast.GenDecl, n *ast.ValueSpec) *Terminal {
 	}
 	pos := gd.Pos()
 	end := gd.End()
+	// the comments in the values aren't free-floating comments
+	v.deleteComments(n.Pos(), n.End())
 	if n.Comment != nil {
 		end = n.Comment.End()
 		delete(v.Comments, n.Comment)
@@ -802,6 +1121,7 @@ func (v *visitor) createVar(gd *ast.GenDecl, n *ast.ValueSpec) *Terminal {
 	return &Terminal{
 		Type:         VarNode,
 		Name:         n.Names[0].Name,
+		Deprecated:   isDeprecated(gd.Doc, n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
 	}
@@ -814,6 +1134,7 @@ func (v *visitor) createVarGroup(n *ast.GenDecl) *Container {
 	c := &Container{
 		Type:         VarNode,
 		Name:         "var",
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromNode(v.FileSet, n),
 		HeaderSpan:   runeSpanFromPositions(v.FileSet, n.Pos(), n.Lparen),
 		FooterSpan:   runeSpanFromPositions(v.FileSet, n.Rparen, n.End()),
@@ -830,6 +1151,8 @@ func (v *visitor) createVarInGroup(n *ast.ValueSpec) *Terminal {
 	}
 	pos := n.Pos()
 	end := n.End()
+	// the comments in the values aren't free-floating comments
+	v.deleteComments(n.Pos(), n.End())
 	if n.Comment != nil {
 		end = n.Comment.End()
 		delete(v.Comments, n.Comment)
@@ -837,6 +1160,7 @@ func (v *visitor) createVarInGroup(n *ast.ValueSpec) *Terminal {
 	return &Terminal{
 		Type:         VarNode,
 		Name:         n.Names[0].Name,
+		Deprecated:   isDeprecated(n.Doc),
 		LocationSpan: locationSpanFromPositions(v.FileSet, pos, end),
 		Span:         runeSpanFromPositions(v.FileSet, pos, end),
 	}
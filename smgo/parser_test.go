@@ -2,12 +2,15 @@ package smgo_test
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/jriquelme/SemanticMergeGO/smgo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newLocationSpan(startLine, startColumn, endLine, endColumn int) smgo.LocationSpan {
@@ -23,6 +26,30 @@ func newLocationSpan(startLine, startColumn, endLine, endColumn int) smgo.Locati
 	}
 }
 
+// assertEqualFiles asserts that the declarations trees are equal, reporting the differences
+// between their dumps if they aren't.
+func assertEqualFiles(t *testing.T, expected, actual *smgo.File, msgAndArgs ...interface{}) bool {
+	if assert.ObjectsAreEqual(expected, actual) {
+		return true
+	}
+	expectedDump, actualDump := dump(t, expected), dump(t, actual)
+	if expectedDump == actualDump {
+		// the differences aren't dumped
+		return assert.Equal(t, expected, actual, msgAndArgs...)
+	}
+	return assert.Equal(t, expectedDump, actualDump, msgAndArgs...)
+}
+
+func dump(t testing.TB, file *smgo.File) string {
+	if file == nil {
+		return "<nil>\n"
+	}
+	var buf bytes.Buffer
+	err := smgo.Dump(&buf, file)
+	require.Nil(t, err)
+	return buf.String()
+}
+
 func TestParseErrUnsupportedEncoding(t *testing.T) {
 	t.Parallel()
 	if testing.Verbose() {
@@ -45,7 +72,7 @@ func TestParseEmpty(t *testing.T) {
 	assert.NotNil(t, file)
 	assert.Nil(t, err)
 
-	assert.Equal(t, &smgo.File{
+	assertEqualFiles(t, &smgo.File{
 		LocationSpan: newLocationSpan(1, 0, 1, 0),
 		FooterSpan:   smgo.RuneSpan{0, -1},
 		Children:     nil,
@@ -56,7 +83,169 @@ func TestParseEmpty(t *testing.T) {
 			},
 		},
 	}, file)
-	if t.Failed() {
-		spew.Dump(t.Name(), file)
+}
+
+func TestParseBytesDoesNotRetainSrc(t *testing.T) {
+	t.Parallel()
+
+	const src = "package bytes\n\n// Name is a name.\nconst Name = \"n\xffme\"\n\ntype T struct {\n\tA int // a\n}\n"
+	opts := [][]smgo.Option{
+		{},
+		{smgo.WithStableIDs()},
+		{smgo.WithInvalidUTF8Policy(smgo.InvalidUTF8PassThrough)},
+		{smgo.WithInvalidUTF8Policy(smgo.InvalidUTF8Replace)},
+		{smgo.WithChunkedParsing(1)},
+	}
+	for _, encoding := range []string{"UTF-8", "Windows-1252"} {
+		for _, opt := range opts {
+			expected, err := smgo.Parse(strings.NewReader(src), encoding, opt...)
+			assert.Nil(t, err)
+
+			buf := []byte(src)
+			file, err := smgo.ParseBytes(buf, encoding, opt...)
+			assert.Nil(t, err)
+			assert.Equal(t, src, string(buf), "src modified")
+			// reuse the buffer
+			for i := range buf {
+				buf[i] = 'x'
+			}
+			assertEqualFiles(t, expected, file)
+		}
+	}
+}
+
+func TestParseExported(t *testing.T) {
+	t.Parallel()
+
+	src := "package Exported\n\nimport Fmt \"fmt\"\n\nconst (\n\tA = 1\n\tb = 2\n)\n\n// ÑT is exported.\ntype ÑT struct {\n\tName string\n\tage  int\n}\n\nfunc (ÑT) String() string {\n\treturn Fmt.Sprint(\"t\")\n}\n\nfunc do() {\n}\n"
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Equal(t, map[string]bool{
+		"Exported": false,
+		"fmt":      false,
+		"const":    false,
+		"A":        true,
+		"b":        false,
+		"ÑT":       true,
+		"Name":     true,
+		"age":      false,
+		"String":   true,
+		"do":       false,
+	}, nodeFlags(file, func(t *smgo.Terminal) bool {
+		return t.Exported
+	}, func(c *smgo.Container) bool {
+		return c.Exported
+	}))
+}
+
+func TestParseDeprecated(t *testing.T) {
+	t.Parallel()
+
+	src := `package deprecated
+
+// A does a.
+//
+// Deprecated: use B.
+func A() {}
+
+// B isn't Deprecated: it's new.
+func B() {}
+
+// Deprecated: don't use these.
+const (
+	C = 1
+	// Deprecated: use C.
+	D = 2
+)
+
+type T struct {
+	// Deprecated: use Y.
+	X int
+	Y int
+}
+`
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Equal(t, map[string]bool{
+		"deprecated": false,
+		"A":          true,
+		"B":          false,
+		"const":      true,
+		"C":          false,
+		"D":          true,
+		"T":          false,
+		"X":          true,
+		"Y":          false,
+	}, nodeFlags(file, func(t *smgo.Terminal) bool {
+		return t.Deprecated
+	}, func(c *smgo.Container) bool {
+		return c.Deprecated
+	}))
+}
+
+// nodeFlags maps the names of the nodes of file to a flag of the nodes.
+func nodeFlags(file *smgo.File, terminalFlag func(*smgo.Terminal) bool, containerFlag func(*smgo.Container) bool) map[string]bool {
+	flags := make(map[string]bool)
+	var walk func(nodes []smgo.Node)
+	walk = func(nodes []smgo.Node) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *smgo.Terminal:
+				flags[n.Name] = terminalFlag(n)
+			case *smgo.Container:
+				flags[n.Name] = containerFlag(n)
+				walk(n.Children)
+			}
+		}
+	}
+	walk(file.Children)
+	return flags
+}
+
+// manyFuncs returns the source code of a package with n commented functions.
+func manyFuncs(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("package many\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "\n// F%d does nothing.\nfunc F%d() { // trailing\n\t// body\n\t_ = %d // value\n}\n", i, i, i)
+	}
+	return buf.Bytes()
+}
+
+// TestParseManyFuncsScaling guards against parsing times growing faster than the number of
+// declarations, e.g. scanning every comment for every declaration.
+func TestParseManyFuncsScaling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	parseTime := func(n int) time.Duration {
+		src := manyFuncs(n)
+		best := time.Duration(math.MaxInt64)
+		for i := 0; i < 3; i++ {
+			start := time.Now()
+			file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+			elapsed := time.Since(start)
+			require.Nil(t, err)
+			require.Len(t, file.Children, n+1)
+			if elapsed < best {
+				best = elapsed
+			}
+		}
+		return best
+	}
+	small, large := parseTime(1000), parseTime(8000)
+	// 8 times the declarations: 8 times the time if linear, 64 times if quadratic
+	assert.True(t, large < 24*small, "1000 functions in %s, 8000 in %s", small, large)
+}
+
+func BenchmarkParseManyFuncs(b *testing.B) {
+	src := manyFuncs(8000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+		if err != nil {
+			b.Fatal(err)
+		}
 	}
 }
@@ -0,0 +1,101 @@
+package smgo
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidRange = errors.New("Invalid range")
+
+// ParseRange parses the top-level declarations of the UTF-8 encoded GO source code in src
+// intersecting the byte range [start, end) (or containing start, if start == end), and returns
+// their nodes, with the spans and locations of the whole source code. Only the source code
+// from the end of the declaration before the range to the end of the last one in the range
+// is parsed (unless it can't be parsed on its own), so tools can refresh a small region of a
+// big file cheaply. Options about the encoding, preprocessing, chunks and columns don't
+// apply.
+func ParseRange(src []byte, start, end int, opts ...Option) ([]Node, error) {
+	if start < 0 || end < start || end > len(src) {
+		return nil, ErrInvalidRange
+	}
+	cfg := newConfig(opts)
+
+	// every cut is the beginning of a line after a top-level declaration
+	cuts := chunkCuts(src, 0)
+	lo, hi := 0, len(src)
+	if i := sort.SearchInts(cuts, start+1); i > 0 {
+		lo = cuts[i-1]
+	}
+	last := end
+	if last == start {
+		last++
+	}
+	if i := sort.SearchInts(cuts, last); i < len(cuts) {
+		hi = cuts[i]
+	}
+
+	nodes, ok, err := parseRegion(src, lo, hi, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		file, err := parseSrc(src, true, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if len(file.ParsingErrors) > 0 {
+			return nil, ErrSrcHasErrors
+		}
+		nodes = file.Children
+	}
+
+	var inRange []Node
+	for _, node := range nodes {
+		nodeStart, nodeEnd := nodeRange(node)
+		if start == end && nodeStart <= start && start <= nodeEnd ||
+			start < end && nodeStart < end && nodeEnd >= start {
+			inRange = append(inRange, node)
+		}
+	}
+	if cfg.nfcNames {
+		normalizeNames(inRange)
+	}
+	if cfg.metrics {
+		setMetrics(inRange, src)
+	}
+	return inRange, nil
+}
+
+// parseRegion parses the top-level declarations of src[lo:hi], where lo and hi are 0, len(src)
+// or cuts between declarations. ok is false if the region can't be parsed on its own.
+func parseRegion(src []byte, lo, hi int, cfg *config) (nodes []Node, ok bool, err error) {
+	region := src[lo:hi]
+	if lo > 0 {
+		region = make([]byte, 0, len(chunkPrefix)+hi-lo)
+		region = append(region, chunkPrefix...)
+		region = append(region, src[lo:hi]...)
+	}
+	file, err := parseSrc(region, true, cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(file.ParsingErrors) > 0 || hi < len(src) && file.FooterSpan.End >= file.FooterSpan.Start {
+		return nil, false, nil
+	}
+	if lo == 0 {
+		return file.Children, true, nil
+	}
+
+	// the region starts at the beginning of a line, the second one of the parsed source code
+	lineDelta := bytes.Count(src[:lo], []byte("\n")) - 1
+	offsetDelta := lo - len(chunkPrefix)
+	nodes = file.Children[1:]
+	shiftNodes(nodes, func(offset int) int {
+		return offset + offsetDelta
+	}, func(l *Location) {
+		l.Line += lineDelta
+	})
+	return nodes, true, nil
+}
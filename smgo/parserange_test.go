@@ -0,0 +1,59 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(incrementalSrc)
+	opts := []smgo.Option{smgo.WithStableIDs(), smgo.WithComplexity(), smgo.WithMetrics()}
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", opts...)
+	require.Nil(t, err)
+	offset := func(s string) int {
+		i := bytes.Index(src, []byte(s))
+		require.NotEqual(t, -1, i, s)
+		return i
+	}
+	// file.Children: package, import, A, T, comment, X, Y, B
+	cases := []struct {
+		Name       string
+		Start, End int
+		First      int
+		Last       int
+	}{
+		{"package", 0, 0, 0, 0},
+		{"func_body", offset(`"a"`), offset(`"a"`) + 3, 2, 2},
+		{"doc_comment", offset("// A"), offset("// A"), 2, 2},
+		{"field", offset("Name"), offset("Name"), 3, 3},
+		{"many", offset("fmt.Println"), offset("Name"), 2, 3},
+		{"same_line", offset("var Y"), offset("var Y"), 6, 6},
+		{"free_comment", offset("// free"), offset("var X") + 1, 4, 5},
+		{"end", len(src) - 1, len(src) - 1, 7, 7},
+		{"all", 0, len(src), 0, 7},
+	}
+	for _, c := range cases {
+		nodes, err := smgo.ParseRange(src, c.Start, c.End, opts...)
+		require.Nil(t, err, c.Name)
+		expected := &smgo.File{Children: file.Children[c.First : c.Last+1]}
+		assertEqualFiles(t, expected, &smgo.File{Children: nodes}, c.Name)
+	}
+}
+
+func TestParseRangeErrors(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package a\n\nfunc A( {\n}\n\nfunc B() {\n}\n")
+	_, err := smgo.ParseRange(src, 5, 4)
+	assert.Equal(t, smgo.ErrInvalidRange, err)
+	_, err = smgo.ParseRange(src, 0, len(src)+1)
+	assert.Equal(t, smgo.ErrInvalidRange, err)
+	_, err = smgo.ParseRange(src, bytes.Index(src, []byte("B()")), len(src))
+	assert.Equal(t, smgo.ErrSrcHasErrors, err)
+}
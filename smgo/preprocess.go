@@ -0,0 +1,283 @@
+package smgo
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Transformer transforms the source code before it's parsed, e.g. formatting it or sorting
+// its imports. It must neither modify nor retain src.
+type Transformer func(src []byte) ([]byte, error)
+
+// WithPreprocess applies transformers, in order, to the decoded source code before parsing
+// it. The spans and locations of the declarations tree are mapped back to the original
+// source code: unchanged lines map exactly, while offsets in changed lines map to the same
+// distance from the start of the original lines (or to their end). If a transformer fails,
+// the source code is parsed as it was before that transformer, with a warning.
+func WithPreprocess(transformers ...Transformer) Option {
+	return func(cfg *config) {
+		cfg.transformers = append(cfg.transformers, transformers...)
+	}
+}
+
+// offsetMap maps the offsets of a transformed source code to the offsets of the source code
+// before the transformation.
+type offsetMap struct {
+	hunks []hunk
+}
+
+// hunk is a changed region: [NewStart, NewEnd) in the transformed source code replaced
+// [OldStart, OldEnd) of the original.
+type hunk struct {
+	OldStart, OldEnd int
+	NewStart, NewEnd int
+}
+
+// preprocess applies transformers to src, returning the transformed source code and the
+// maps from its offsets to the offsets of src, in reverse order of application.
+func preprocess(src []byte, transformers []Transformer) ([]byte, []*offsetMap, []*Warning) {
+	var maps []*offsetMap
+	var warnings []*Warning
+	for _, transform := range transformers {
+		transformed, err := transform(src)
+		if err != nil {
+			warnings = append(warnings, &Warning{
+				Location: Location{1, 0},
+				Message:  "preprocessing failed: " + err.Error(),
+			})
+			break
+		}
+		maps = append([]*offsetMap{newOffsetMap(src, transformed)}, maps...)
+		src = transformed
+	}
+	return src, maps, warnings
+}
+
+// newOffsetMap diffs the lines of src and transformed.
+func newOffsetMap(src, transformed []byte) *offsetMap {
+	a, b := splitLines(src), splitLines(transformed)
+	aStarts, bStarts := lineOffsets(a), lineOffsets(b)
+	m := &offsetMap{}
+	// addHunk adds the hunk replacing the lines [i, iEnd) of a with the lines [j, jEnd) of
+	// b. Lines replaced one by one, as usual when formatting, are mapped line by line.
+	addHunk := func(i, iEnd, j, jEnd int) {
+		if i == iEnd && j == jEnd {
+			return
+		}
+		if iEnd-i == jEnd-j {
+			for ; i < iEnd; i, j = i+1, j+1 {
+				m.hunks = append(m.hunks, hunk{aStarts[i], aStarts[i+1], bStarts[j], bStarts[j+1]})
+			}
+			return
+		}
+		m.hunks = append(m.hunks, hunk{aStarts[i], aStarts[iEnd], bStarts[j], bStarts[jEnd]})
+	}
+	i, j := 0, 0
+	for _, match := range matchLines(a, b) {
+		addHunk(i, match[0], j, match[1])
+		i, j = match[0]+1, match[1]+1
+	}
+	addHunk(i, len(a), j, len(b))
+	return m
+}
+
+// Offset maps the boundary offset of the transformed source code (the offset of a byte, or
+// the length of the text) to the original source code.
+func (m *offsetMap) Offset(offset int) int {
+	i := sort.Search(len(m.hunks), func(i int) bool {
+		return m.hunks[i].NewStart > offset
+	}) - 1
+	if i < 0 {
+		return offset
+	}
+	h := m.hunks[i]
+	if offset < h.NewEnd {
+		if d := offset - h.NewStart; d < h.OldEnd-h.OldStart {
+			return h.OldStart + d
+		}
+		return h.OldEnd
+	}
+	return offset - h.NewEnd + h.OldEnd
+}
+
+// remapFile maps the spans and locations of file, built from the transformed source code,
+// back to the original source code src.
+func remapFile(file *File, transformed, src []byte, maps []*offsetMap) {
+	transformedLines := lineStarts(transformed)
+	srcLines := lineStarts(src)
+	mapOffset := func(offset int) int {
+		for _, m := range maps {
+			offset = m.Offset(offset)
+		}
+		return offset
+	}
+	mapSpan := func(span *RuneSpan) {
+		if span.End < span.Start {
+			// empty span
+			span.Start = mapOffset(span.Start)
+			span.End = span.Start - 1
+			return
+		}
+		span.Start, span.End = mapOffset(span.Start), mapOffset(span.End+1)-1
+	}
+	// columns are mapped relative to the start of their line, so the end of a line (the
+	// column after its '\n') stays in the same line
+	mapLocation := func(l *Location) {
+		offset := locationOffset(transformedLines, *l)
+		line := offsetLocation(srcLines, mapOffset(offset-l.Column)).Line
+		l.Line, l.Column = line, mapOffset(offset)-srcLines[line-1]
+	}
+
+	forEachLocationSpan(file, func(ls *LocationSpan) {
+		mapLocation(&ls.Start)
+		mapLocation(&ls.End)
+	})
+	mapSpan(&file.FooterSpan)
+	walkNodes(file.Children, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			mapSpan(&n.Span)
+		case *Container:
+			mapSpan(&n.HeaderSpan)
+			mapSpan(&n.FooterSpan)
+		}
+	})
+	// parsing errors use 1-based columns
+	for _, parsingError := range file.ParsingErrors {
+		if parsingError.Location.Column > 0 {
+			l := &parsingError.Location
+			l.Column--
+			mapLocation(l)
+			l.Column++
+		}
+	}
+}
+
+// matchLines returns the indexes of the matching lines of a and b, in order, using the
+// linear space variant of the Myers diff algorithm: the middle snake of the shortest edit
+// script splits the lines in two smaller diffs.
+func matchLines(a, b []string) [][2]int {
+	max := len(a) + len(b)
+	vf, vb := make([]int, 2*max+3), make([]int, 2*max+3)
+	var matches [][2]int
+	var diff func(aLo, aHi, bLo, bHi int)
+	diff = func(aLo, aHi, bLo, bHi int) {
+		for aLo < aHi && bLo < bHi && a[aLo] == b[bLo] {
+			matches = append(matches, [2]int{aLo, bLo})
+			aLo, bLo = aLo+1, bLo+1
+		}
+		suffix := 0
+		for aLo < aHi-suffix && bLo < bHi-suffix && a[aHi-suffix-1] == b[bHi-suffix-1] {
+			suffix++
+		}
+		aHi, bHi = aHi-suffix, bHi-suffix
+		if aLo < aHi && bLo < bHi {
+			x, y, u, v := middleSnake(a[aLo:aHi], b[bLo:bHi], vf, vb, max+1)
+			diff(aLo, aLo+x, bLo, bLo+y)
+			for ; x < u; x, y = x+1, y+1 {
+				matches = append(matches, [2]int{aLo + x, bLo + y})
+			}
+			diff(aLo+u, aHi, bLo+v, bHi)
+		}
+		for i := 0; i < suffix; i++ {
+			matches = append(matches, [2]int{aHi + i, bHi + i})
+		}
+	}
+	diff(0, len(a), 0, len(b))
+	return matches
+}
+
+// middleSnake returns the snake, from (x, y) to (u, v), in the middle of a shortest edit
+// script of a and b, searching from both ends at once. vf and vb are the furthest reaching x
+// of the diagonals forwards and backwards, offset by center (and by the difference of the
+// lengths, for vb). a and b must differ in their
+// first and last lines, so the edit script has at least two edits.
+func middleSnake(a, b []string, vf, vb []int, center int) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	for d := 0; d <= (n+m+1)/2; d++ {
+		for k := -d; k <= d; k += 2 {
+			if d == 0 {
+				x = 0
+			} else if k == -d || (k != d && vf[center+k-1] < vf[center+k+1]) {
+				x = vf[center+k+1]
+			} else {
+				x = vf[center+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u, v = u+1, v+1
+			}
+			vf[center+k] = u
+			if delta%2 != 0 && k >= delta-(d-1) && k <= delta+(d-1) && u >= vb[center+k-delta] {
+				return x, y, u, v
+			}
+		}
+		for k := delta - d; k <= delta+d; k += 2 {
+			if d == 0 {
+				u = n
+			} else if k == delta-d || (k != delta+d && vb[center+k-delta+1]-1 < vb[center+k-delta-1]) {
+				u = vb[center+k-delta+1] - 1
+			} else {
+				u = vb[center+k-delta-1]
+			}
+			v = u - k
+			x, y = u, v
+			for x > 0 && y > 0 && a[x-1] == b[y-1] {
+				x, y = x-1, y-1
+			}
+			vb[center+k-delta] = x
+			if delta%2 == 0 && k >= -d && k <= d && x <= vf[center+k] {
+				return x, y, u, v
+			}
+		}
+	}
+	panic("no middle snake")
+}
+
+// splitLines splits src after every '\n'.
+func splitLines(src []byte) []string {
+	var lines []string
+	for len(src) > 0 {
+		i := bytes.IndexByte(src, '\n') + 1
+		if i == 0 {
+			i = len(src)
+		}
+		lines = append(lines, string(src[:i]))
+		src = src[i:]
+	}
+	return lines
+}
+
+// lineOffsets returns the offset of every line, plus the length of the text.
+func lineOffsets(lines []string) []int {
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+	return offsets
+}
+
+// locationOffset returns the offset of l, a location with a 0-based column.
+func locationOffset(lineStarts []int, l Location) int {
+	line := l.Line
+	if line < 1 {
+		line = 1
+	}
+	if line > len(lineStarts) {
+		line = len(lineStarts)
+	}
+	return lineStarts[line-1] + l.Column
+}
+
+// offsetLocation returns the location, with a 0-based column, of offset.
+func offsetLocation(lineStarts []int, offset int) Location {
+	line := sort.Search(len(lineStarts), func(i int) bool {
+		return lineStarts[i] > offset
+	})
+	if line < 1 {
+		line = 1
+	}
+	return Location{line, offset - lineStarts[line-1]}
+}
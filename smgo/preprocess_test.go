@@ -0,0 +1,82 @@
+package smgo_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithPreprocess(t *testing.T) {
+	t.Parallel()
+
+	src := "package preprocess\n\n\n\nfunc  A( ) {\n}\n\n// T is a type.\ntype T struct {\n\tName   string\n\tAge int\n}\n\nvar X=1\n"
+	expected, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+
+	var formatted []byte
+	gofmt := func(src []byte) ([]byte, error) {
+		formatted, err = format.Source(src)
+		return formatted, err
+	}
+	upper := func(src []byte) ([]byte, error) {
+		return bytes.Replace(src, []byte("func A"), []byte("func a"), 1), nil
+	}
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithPreprocess(gofmt, upper))
+	require.Nil(t, err)
+	require.NotEqual(t, src, string(formatted))
+	require.Len(t, file.Children, 4)
+	assert.Equal(t, "a", file.Children[1].(*smgo.Terminal).Name)
+	file.Children[1].(*smgo.Terminal).Name = "A"
+	file.Children[1].(*smgo.Terminal).Exported = true
+	assertEqualFiles(t, expected, file)
+}
+
+func TestParseWithPreprocessLargeDiff(t *testing.T) {
+	t.Parallel()
+
+	// every other line changes: the diff is too large to keep a copy of the diagonals per edit
+	var src strings.Builder
+	src.WriteString("package preprocess\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&src, "var v%d = %d\n", i, i)
+	}
+	compact := func(src []byte) ([]byte, error) {
+		lines := bytes.SplitAfter(src, []byte("\n"))
+		for i := 1; i < len(lines); i += 2 {
+			lines[i] = bytes.Replace(lines[i], []byte(" = "), []byte("="), 1)
+		}
+		return bytes.Join(lines, nil), nil
+	}
+	file, err := smgo.Parse(strings.NewReader(src.String()), "UTF-8", smgo.WithPreprocess(compact))
+	require.Nil(t, err)
+	assert.Empty(t, file.Warnings)
+	assert.Empty(t, smgo.CheckSpans(file, src.Len()))
+	require.Len(t, file.Children, 5001)
+	assert.Equal(t, newLocationSpan(5001, 0, 5001, 17), file.Children[5000].(*smgo.Terminal).LocationSpan)
+}
+
+func TestParseWithFailingPreprocess(t *testing.T) {
+	t.Parallel()
+
+	src := "package preprocess\n\nfunc A() {\n}\n"
+	expected, err := smgo.Parse(strings.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+
+	fail := func(src []byte) ([]byte, error) {
+		return nil, errors.New("boom")
+	}
+	file, err := smgo.Parse(strings.NewReader(src), "UTF-8", smgo.WithPreprocess(fail))
+	require.Nil(t, err)
+	assert.Equal(t, []*smgo.Warning{
+		{Location: smgo.Location{1, 0}, Message: "preprocessing failed: boom"},
+	}, file.Warnings)
+	file.Warnings = nil
+	assert.Equal(t, expected, file)
+}
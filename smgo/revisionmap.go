@@ -0,0 +1,121 @@
+package smgo
+
+import (
+	"bytes"
+	"sort"
+)
+
+// RevisionMap translates the offsets of a revision of a source code to the offsets of
+// another revision, through the declarations matched between their declarations trees: the
+// ones with the same type and qualified name (e.g. "Person.SayHi" for a method), found once
+// in each tree. Declarations in groups are matched regardless of their group.
+type RevisionMap struct {
+	regions []matchedRegion
+}
+
+// matchedRegion is a span of the old revision (a terminal, or the header or footer of a
+// container) and its matching span of the new revision. maps translates the offsets of a
+// changed region; it's nil if the texts of both spans are equal.
+type matchedRegion struct {
+	old, new RuneSpan
+	maps     *offsetMap
+}
+
+// NewRevisionMap returns the RevisionMap from oldFile, the declarations tree of oldSrc, to
+// newFile, the declarations tree of newSrc.
+func NewRevisionMap(oldFile *File, oldSrc []byte, newFile *File, newSrc []byte) *RevisionMap {
+	oldSpans, oldKeys := revisionSpans(oldFile)
+	newSpans, _ := revisionSpans(newFile)
+	m := &RevisionMap{}
+	for _, key := range oldKeys {
+		old, new := oldSpans[key], newSpans[key]
+		if old == nil || new == nil || old.End < old.Start || new.End < new.Start {
+			continue
+		}
+		region := matchedRegion{old: *old, new: *new}
+		oldText, newText := oldSrc[old.Start:old.End+1], newSrc[new.Start:new.End+1]
+		if !bytes.Equal(oldText, newText) {
+			// the offsets of oldText are the ones of a transformation of newText
+			region.maps = newOffsetMap(newText, oldText)
+		}
+		m.regions = append(m.regions, region)
+	}
+	sort.Slice(m.regions, func(i, j int) bool {
+		return m.regions[i].old.Start < m.regions[j].old.Start
+	})
+	return m
+}
+
+// MapOffset returns the offset in the new revision of oldOffset. ok is false if oldOffset
+// isn't in a matched declaration, or it's in a line changed between the revisions, in which
+// case newOffset is its best guess (or -1, if there's none).
+func (m *RevisionMap) MapOffset(oldOffset int) (newOffset int, ok bool) {
+	i := sort.Search(len(m.regions), func(i int) bool {
+		return m.regions[i].old.Start > oldOffset
+	}) - 1
+	if i < 0 || oldOffset > m.regions[i].old.End {
+		return -1, false
+	}
+	region := m.regions[i]
+	offset := oldOffset - region.old.Start
+	if region.maps == nil {
+		return region.new.Start + offset, true
+	}
+	ok = true
+	for _, h := range region.maps.hunks {
+		if offset >= h.NewStart && offset < h.NewEnd {
+			ok = false
+			break
+		}
+	}
+	newOffset = region.new.Start + region.maps.Offset(offset)
+	if newOffset > region.new.End {
+		newOffset = region.new.End
+	}
+	return newOffset, ok
+}
+
+// revisionSpans returns the spans of the declarations of file by key, and the keys in
+// order. The spans of the keys found more than once are nil.
+func revisionSpans(file *File) (map[string]*RuneSpan, []string) {
+	spans := make(map[string]*RuneSpan)
+	var keys []string
+	add := func(key string, span RuneSpan) {
+		if _, ok := spans[key]; ok {
+			spans[key] = nil
+			return
+		}
+		spans[key] = &span
+		keys = append(keys, key)
+	}
+	var collect func(nodes []Node, qualifier string)
+	collect = func(nodes []Node, qualifier string) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *Terminal:
+				name := n.Name
+				if n.Receiver != "" {
+					name = n.Receiver + "." + name
+				}
+				add(n.Type.String()+" "+qualifier+name, n.Span)
+			case *Container:
+				key := n.Type.String() + " " + qualifier + n.Name
+				add(key+" {", n.HeaderSpan)
+				add(key+" }", n.FooterSpan)
+				switch n.Type {
+				case ImportNode, ConstNode, VarNode, TypeNode:
+					// declaration group
+					collect(n.Children, qualifier)
+				default:
+					collect(n.Children, qualifier+n.Name+".")
+				}
+			}
+		}
+	}
+	if file.HeaderSpan != nil {
+		add("header", *file.HeaderSpan)
+	}
+	collect(file.Children, "")
+	add("footer", file.FooterSpan)
+	return spans, keys
+}
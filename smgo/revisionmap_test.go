@@ -0,0 +1,81 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevisionMap(t *testing.T) {
+	t.Parallel()
+
+	oldSrc := []byte(`package revision
+
+// A does a.
+func A() {
+	println("a")
+	println("aa")
+}
+
+type T struct {
+	Name string
+}
+
+func (t T) B() {
+}
+
+func C() {
+}
+`)
+	newSrc := []byte(`package revision
+
+type T struct {
+	Age  int
+	Name string
+}
+
+// A does a.
+func A() {
+	println("a")
+	println("changed")
+}
+
+func (t T) B() {
+}
+`)
+	oldFile, err := smgo.Parse(bytes.NewReader(oldSrc), "UTF-8")
+	require.Nil(t, err)
+	newFile, err := smgo.Parse(bytes.NewReader(newSrc), "UTF-8")
+	require.Nil(t, err)
+	m := smgo.NewRevisionMap(oldFile, oldSrc, newFile, newSrc)
+
+	cases := []struct {
+		Name     string
+		Old, New string
+		Ok       bool
+	}{
+		{"package", "revision", "revision", true},
+		{"moved_func", `"a"`, `"a"`, true},
+		{"moved_doc", "A does", "A does", true},
+		{"changed_line", `"aa"`, `"changed"`, false},
+		{"after_changed_line", "}\n\ntype", "}\n\nfunc (t", true},
+		{"field", "Name string", "Name string", true},
+		{"method", "B()", "B()", true},
+	}
+	for _, c := range cases {
+		newOffset, ok := m.MapOffset(bytes.Index(oldSrc, []byte(c.Old)))
+		assert.Equal(t, c.Ok, ok, c.Name)
+		assert.Equal(t, bytes.Index(newSrc, []byte(c.New)), newOffset, c.Name)
+	}
+
+	// removed declaration
+	newOffset, ok := m.MapOffset(bytes.Index(oldSrc, []byte("C()")))
+	assert.False(t, ok)
+	assert.Equal(t, -1, newOffset)
+	// out of the file
+	_, ok = m.MapOffset(len(oldSrc) + 10)
+	assert.False(t, ok)
+}
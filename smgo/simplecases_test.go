@@ -5,7 +5,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/jriquelme/SemanticMergeGO/smgo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,12 +35,14 @@ func TestParseSimpleCases(t *testing.T) {
 					&smgo.Terminal{
 						Type:         smgo.ConstNode,
 						Name:         "N",
+						Exported:     true,
 						LocationSpan: newLocationSpan(2, 0, 3, 12),
 						Span:         smgo.RuneSpan{20, 32},
 					},
 					&smgo.Terminal{
 						Type:         smgo.ConstNode,
 						Name:         "Name",
+						Exported:     true,
 						LocationSpan: newLocationSpan(4, 0, 5, 25),
 						Span:         smgo.RuneSpan{33, 58},
 					},
@@ -49,6 +50,90 @@ func TestParseSimpleCases(t *testing.T) {
 				ParsingErrors: nil,
 			},
 		},
+		{
+			Src: "simple_embedded.go",
+			ExpectedFile: &smgo.File{
+				LocationSpan: newLocationSpan(1, 0, 18, 2),
+				FooterSpan:   smgo.RuneSpan{0, -1},
+				Children: []smgo.Node{
+					&smgo.Terminal{
+						Type:         smgo.PackageNode,
+						Name:         "simpleembedded",
+						LocationSpan: newLocationSpan(1, 0, 1, 23),
+						Span:         smgo.RuneSpan{0, 22},
+					},
+					&smgo.Terminal{
+						Type:         smgo.ImportNode,
+						Name:         "io",
+						LocationSpan: newLocationSpan(2, 0, 3, 12),
+						Span:         smgo.RuneSpan{23, 35},
+					},
+					&smgo.Container{
+						Type:         smgo.StructNode,
+						Name:         "Person",
+						Exported:     true,
+						LocationSpan: newLocationSpan(4, 0, 13, 2),
+						HeaderSpan:   smgo.RuneSpan{36, 57},
+						FooterSpan:   smgo.RuneSpan{158, 159},
+						Children: []smgo.Node{
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "io.Reader",
+								Exported:     true,
+								LocationSpan: newLocationSpan(6, 0, 6, 11),
+								Span:         smgo.RuneSpan{58, 68},
+							},
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "*Person",
+								Exported:     true,
+								LocationSpan: newLocationSpan(7, 0, 7, 9),
+								Span:         smgo.RuneSpan{69, 77},
+							},
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "Name",
+								Exported:     true,
+								LocationSpan: newLocationSpan(8, 0, 8, 16),
+								Span:         smgo.RuneSpan{78, 93},
+							},
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "Address",
+								Exported:     true,
+								LocationSpan: newLocationSpan(9, 0, 12, 3),
+								Span:         smgo.RuneSpan{94, 157},
+							},
+						},
+					},
+					&smgo.Container{
+						Type:         smgo.InterfaceNode,
+						Name:         "ReadCloser",
+						Exported:     true,
+						LocationSpan: newLocationSpan(14, 0, 18, 2),
+						HeaderSpan:   smgo.RuneSpan{160, 188},
+						FooterSpan:   smgo.RuneSpan{215, 216},
+						Children: []smgo.Node{
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "io.Reader",
+								Exported:     true,
+								LocationSpan: newLocationSpan(16, 0, 16, 11),
+								Span:         smgo.RuneSpan{189, 199},
+							},
+							&smgo.Terminal{
+								Type:         smgo.FieldNode,
+								Name:         "Close",
+								Exported:     true,
+								LocationSpan: newLocationSpan(17, 0, 17, 15),
+								Span:         smgo.RuneSpan{200, 214},
+							},
+						},
+					},
+				},
+				ParsingErrors: nil,
+			},
+		},
 		{
 			Src: "simple_footer.go_src",
 			ExpectedFile: &smgo.File{
@@ -86,6 +171,7 @@ func TestParseSimpleCases(t *testing.T) {
 					&smgo.Terminal{
 						Type:         smgo.FunctionNode,
 						Name:         "Hi",
+						Exported:     true,
 						LocationSpan: newLocationSpan(2, 0, 5, 2),
 						Span:         smgo.RuneSpan{19, 47},
 					},
@@ -130,6 +216,7 @@ func TestParseSimpleCases(t *testing.T) {
 					&smgo.Container{
 						Type:         smgo.InterfaceNode,
 						Name:         "Figure",
+						Exported:     true,
 						LocationSpan: newLocationSpan(2, 0, 5, 2),
 						HeaderSpan:   smgo.RuneSpan{24, 48},
 						FooterSpan:   smgo.RuneSpan{65, 66},
@@ -137,6 +224,7 @@ func TestParseSimpleCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.FieldNode,
 								Name:         "Area",
+								Exported:     true,
 								LocationSpan: newLocationSpan(4, 0, 4, 16),
 								Span:         smgo.RuneSpan{49, 64},
 							},
@@ -161,6 +249,7 @@ func TestParseSimpleCases(t *testing.T) {
 					&smgo.Container{
 						Type:         smgo.StructNode,
 						Name:         "Person",
+						Exported:     true,
 						LocationSpan: newLocationSpan(2, 0, 5, 2),
 						HeaderSpan:   smgo.RuneSpan{21, 42},
 						FooterSpan:   smgo.RuneSpan{56, 57},
@@ -168,6 +257,7 @@ func TestParseSimpleCases(t *testing.T) {
 							&smgo.Terminal{
 								Type:         smgo.FieldNode,
 								Name:         "Name",
+								Exported:     true,
 								LocationSpan: newLocationSpan(4, 0, 4, 13),
 								Span:         smgo.RuneSpan{43, 55},
 							},
@@ -176,6 +266,8 @@ func TestParseSimpleCases(t *testing.T) {
 					&smgo.Terminal{
 						Type:         smgo.FunctionNode,
 						Name:         "SayHi",
+						Exported:     true,
+						Receiver:     "Person",
 						LocationSpan: newLocationSpan(6, 0, 9, 2),
 						Span:         smgo.RuneSpan{58, 115},
 					},
@@ -204,54 +296,64 @@ func TestParseSimpleCases(t *testing.T) {
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "String",
+						Exported:     true,
 						LocationSpan: newLocationSpan(4, 0, 5, 19),
 						Span:         smgo.RuneSpan{33, 52},
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "StringAlias",
+						Exported:     true,
 						LocationSpan: newLocationSpan(6, 0, 7, 26),
 						Span:         smgo.RuneSpan{53, 79},
+						Alias:        true,
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "Map",
+						Exported:     true,
 						LocationSpan: newLocationSpan(8, 0, 9, 25),
 						Span:         smgo.RuneSpan{80, 105},
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "Array",
+						Exported:     true,
 						LocationSpan: newLocationSpan(10, 0, 11, 18),
 						Span:         smgo.RuneSpan{106, 124},
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "Chan",
+						Exported:     true,
 						LocationSpan: newLocationSpan(12, 0, 13, 21),
 						Span:         smgo.RuneSpan{125, 146},
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "Func",
+						Exported:     true,
 						LocationSpan: newLocationSpan(14, 0, 15, 23),
 						Span:         smgo.RuneSpan{147, 170},
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "IntPointer",
+						Exported:     true,
 						LocationSpan: newLocationSpan(16, 0, 17, 21),
 						Span:         smgo.RuneSpan{171, 192},
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "RedundantPar",
+						Exported:     true,
 						LocationSpan: newLocationSpan(18, 0, 19, 25),
 						Span:         smgo.RuneSpan{193, 218},
 					},
 					&smgo.Terminal{
 						Type:         smgo.TypeNode,
 						Name:         "Reader",
+						Exported:     true,
 						LocationSpan: newLocationSpan(20, 0, 21, 22),
 						Span:         smgo.RuneSpan{219, 241},
 					},
@@ -274,12 +376,14 @@ func TestParseSimpleCases(t *testing.T) {
 					&smgo.Terminal{
 						Type:         smgo.VarNode,
 						Name:         "X",
+						Exported:     true,
 						LocationSpan: newLocationSpan(2, 0, 3, 10),
 						Span:         smgo.RuneSpan{18, 28},
 					},
 					&smgo.Terminal{
 						Type:         smgo.VarNode,
 						Name:         "Z",
+						Exported:     true,
 						LocationSpan: newLocationSpan(4, 0, 5, 21),
 						Span:         smgo.RuneSpan{29, 50},
 					},
@@ -299,10 +403,7 @@ func TestParseSimpleCases(t *testing.T) {
 			assert.NotNil(t, file)
 			assert.Nil(t, err)
 
-			assert.Equal(t, simpleCase.ExpectedFile, file)
-			if t.Failed() {
-				spew.Dump(t.Name(), file)
-			}
+			assertEqualFiles(t, simpleCase.ExpectedFile, file)
 		})
 	}
 }
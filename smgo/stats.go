@@ -0,0 +1,22 @@
+package smgo
+
+import "time"
+
+// Stats are the durations of the phases of a parse. Parse is the time spent by go/parser,
+// Visit building the declarations tree from its AST, and Fix adjusting the boundaries of the
+// blocks; Total includes the phases and the post-processing required by the options.
+type Stats struct {
+	Decode     time.Duration
+	Preprocess time.Duration
+	Parse      time.Duration
+	Visit      time.Duration
+	Fix        time.Duration
+	Total      time.Duration
+}
+
+// WithStats records the durations of the phases of the parse in File.Stats.
+func WithStats() Option {
+	return func(cfg *config) {
+		cfg.recordStats = true
+	}
+}
@@ -0,0 +1,40 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithStats(t *testing.T) {
+	t.Parallel()
+
+	upper := func(src []byte) ([]byte, error) {
+		return bytes.ToUpper(src), nil
+	}
+	src := []byte("package stats\n\n// A does a.\nfunc A() {\n}\n\nfunc B() {\n}\n")
+	file, err := smgo.ParseBytes(src, "UTF-8")
+	require.Nil(t, err)
+	assert.Nil(t, file.Stats)
+
+	for _, opts := range [][]smgo.Option{
+		{smgo.WithStats()},
+		{smgo.WithStats(), smgo.WithChunkedParsing(1)},
+		{smgo.WithStats(), smgo.WithPreprocess(upper)},
+	} {
+		file, err := smgo.ParseBytes(src, "UTF-8", opts...)
+		require.Nil(t, err)
+		require.NotNil(t, file.Stats)
+		stats := file.Stats
+		assert.True(t, stats.Total >= stats.Decode+stats.Preprocess+stats.Parse+stats.Visit+stats.Fix, "%+v", stats)
+
+		// the statistics of an incremental reparse are of the reparse
+		_, err = file.ApplyEdits(src, []smgo.TextEdit{{Start: 47, End: 48, NewText: "C"}}, opts...)
+		require.Nil(t, err)
+		require.NotNil(t, file.Stats)
+		assert.True(t, stats != file.Stats)
+	}
+}
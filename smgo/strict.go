@@ -0,0 +1,76 @@
+package smgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithStrictMode makes Parse fail with a *SpecError when the declarations tree of a file
+// without parsing errors violates the constraints of the SemanticMerge external parser
+// specification, which SemanticMerge would mishandle silently. It's meant for development:
+// the checks walk the whole tree after every parse.
+func WithStrictMode() Option {
+	return func(cfg *config) {
+		cfg.strict = true
+	}
+}
+
+// SpecError is the error of a parse in strict mode, see WithStrictMode. Violations are
+// located like warnings, with 0-based columns.
+type SpecError struct {
+	Violations []*Warning
+}
+
+func (e *SpecError) Error() string {
+	v := e.Violations[0]
+	msg := fmt.Sprintf("Declarations tree violates the specification at %d:%d: %s", v.Location.Line,
+		v.Location.Column, v.Message)
+	if len(e.Violations) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Violations)-1)
+	}
+	return msg
+}
+
+// checkSpec returns a *SpecError if file, the declarations tree of srcLen bytes of source
+// code, violates the specification: its spans must partition the source code (see
+// CheckSpans), nodes must have names and their location spans must not end before they
+// start.
+func checkSpec(file *File, srcLen int) error {
+	if len(file.ParsingErrors) > 0 {
+		return nil
+	}
+	violations := CheckSpans(file, srcLen)
+	checkLocationSpan := func(what string, ls LocationSpan) {
+		if ls.End.Line < ls.Start.Line || ls.End.Line == ls.Start.Line && ls.End.Column < ls.Start.Column {
+			violations = append(violations, &Warning{
+				Location: ls.Start,
+				Message:  fmt.Sprintf("location span of %s ends at %s, before its start", what, ls.End),
+			})
+		}
+	}
+	checkName := func(what, name string, location Location) {
+		if strings.TrimSpace(name) == "" {
+			violations = append(violations, &Warning{
+				Location: location,
+				Message:  fmt.Sprintf("%s has no name", what),
+			})
+		}
+	}
+	checkLocationSpan("the file", file.LocationSpan)
+	walkNodes(file.Children, func(node Node) {
+		switch n := node.(type) {
+		case *Terminal:
+			what := fmt.Sprintf("%s %q", n.Type, n.Name)
+			checkName(what, n.Name, n.LocationSpan.Start)
+			checkLocationSpan(what, n.LocationSpan)
+		case *Container:
+			what := fmt.Sprintf("%s %q", n.Type, n.Name)
+			checkName(what, n.Name, n.LocationSpan.Start)
+			checkLocationSpan(what, n.LocationSpan)
+		}
+	})
+	if len(violations) == 0 {
+		return nil
+	}
+	return &SpecError{Violations: violations}
+}
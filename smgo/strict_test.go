@@ -0,0 +1,43 @@
+package smgo_test
+
+import (
+	"bytes"
+	"go/ast"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithStrictMode(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range readTestdata(t) {
+		_, err := smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithStrictMode())
+		assert.Nil(t, err, "%s", src)
+	}
+
+	// a handler of declarations naming nodes badly
+	noName := smgo.WithDeclHandler(func(decl ast.Decl) (smgo.NodeType, string, bool) {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Name.Name != "B" {
+			return 0, "", false
+		}
+		return smgo.FunctionNode, "", true
+	})
+	src := []byte("package strict\n\nfunc A() {\n}\n\nfunc B() {\n}\n")
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", noName)
+	require.Nil(t, err)
+	_, err = smgo.Parse(bytes.NewReader(src), "UTF-8", noName, smgo.WithStrictMode())
+	require.IsType(t, &smgo.SpecError{}, err)
+	assert.Equal(t, []*smgo.Warning{{
+		Location: smgo.Location{5, 0},
+		Message:  `FunctionNode "" has no name`,
+	}}, err.(*smgo.SpecError).Violations)
+	assert.Equal(t, `Declarations tree violates the specification at 5:0: FunctionNode "" has no name`, err.Error())
+
+	// incremental parses are checked too
+	_, err = file.ApplyEdits(src, []smgo.TextEdit{{13, 13, "nother"}}, noName, smgo.WithStrictMode())
+	assert.IsType(t, &smgo.SpecError{}, err)
+}
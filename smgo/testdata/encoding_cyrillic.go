@@ -0,0 +1,13 @@
+package cyrillic
+
+// Привет, мир
+func Hi() {
+	print("hi!")
+}
+
+// Комментарий к структуре
+
+// Человек
+type Person struct {
+	Name string // Имя
+}
@@ -0,0 +1,18 @@
+package simpleembedded
+
+import "io"
+
+type Person struct {
+	io.Reader
+	*Person
+	Name    string
+	Address struct {
+		// the street and number
+		Street string
+	}
+}
+
+type ReadCloser interface {
+	io.Reader
+	Close() error
+}
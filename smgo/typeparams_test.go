@@ -0,0 +1,116 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTypeParamsAndAliases(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package generics
+
+func Map[T, U any](s []T, f func(T) U) []U {
+	return nil
+}
+
+type List[T any] struct {
+	items []T
+}
+
+func (l *List[T]) Push(item T) {
+	l.items = append(l.items, item)
+}
+
+type Pair[K comparable, V any] interface {
+	Key() K
+}
+
+func (p *pair[K, V]) Key() K {
+	return p.k
+}
+
+type Ints = List[int]
+
+type Point = struct {
+	X, Y int // coordinates
+}
+
+type (
+	Strings = List[string]
+	Set[T comparable] map[T]struct{}
+)
+`)
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Empty(t, smgo.CheckSpans(file, len(src)))
+	assert.Empty(t, file.Warnings)
+	require.Len(t, file.Children, 9)
+
+	fn := file.Children[1].(*smgo.Terminal)
+	assert.Equal(t, smgo.FunctionNode, fn.Type)
+	assert.Equal(t, "Map[T, U]", fn.Name)
+	assert.True(t, fn.Exported)
+
+	list := file.Children[2].(*smgo.Container)
+	assert.Equal(t, smgo.StructNode, list.Type)
+	assert.Equal(t, "List[T]", list.Name)
+	// the type parameters aren't fields
+	assert.Len(t, list.Children, 1)
+	push := file.Children[3].(*smgo.Terminal)
+	assert.Equal(t, "Push", push.Name)
+	assert.Equal(t, "List", push.Receiver)
+
+	pair := file.Children[4].(*smgo.Container)
+	assert.Equal(t, smgo.InterfaceNode, pair.Type)
+	assert.Equal(t, "Pair[K, V]", pair.Name)
+	assert.Len(t, pair.Children, 1)
+	assert.Equal(t, "pair", file.Children[5].(*smgo.Terminal).Receiver)
+
+	// the aliases are types, even if they alias a struct
+	ints := file.Children[6].(*smgo.Terminal)
+	assert.Equal(t, smgo.TypeNode, ints.Type)
+	assert.Equal(t, "Ints", ints.Name)
+	assert.True(t, ints.Alias)
+	point := file.Children[7].(*smgo.Terminal)
+	assert.Equal(t, smgo.TypeNode, point.Type)
+	assert.Equal(t, "Point", point.Name)
+	assert.True(t, point.Alias)
+	assert.Equal(t, newLocationSpan(24, 0, 27, 2), point.LocationSpan)
+
+	group := file.Children[8].(*smgo.Container)
+	assert.Equal(t, smgo.TypeNode, group.Type)
+	require.Len(t, group.Children, 2)
+	assert.Equal(t, "Strings", group.Children[0].(*smgo.Terminal).Name)
+	assert.True(t, group.Children[0].(*smgo.Terminal).Alias)
+	assert.Equal(t, "Set[T]", group.Children[1].(*smgo.Terminal).Name)
+	assert.False(t, group.Children[1].(*smgo.Terminal).Alias)
+
+	// the methods of generic types are gathered under their types
+	pkg := smgo.NewPackageFile(map[string]*smgo.File{"generics.go": file})
+	require.Len(t, pkg.Decls, 8)
+	assert.Equal(t, list, pkg.Decls[1].Node)
+	require.Len(t, pkg.Decls[1].Methods, 1)
+	assert.Equal(t, push, pkg.Decls[1].Methods[0].Node)
+}
+
+func TestParseTypeParamsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package generics
+
+func Map[T any]() {
+}
+
+func Map[U any]() {
+}
+`)
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	require.Len(t, file.Warnings, 1)
+	assert.Equal(t, smgo.Location{Line: 6, Column: 0}, file.Warnings[0].Location)
+}
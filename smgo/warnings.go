@@ -0,0 +1,82 @@
+package smgo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// WithWarningHandler calls handler with every warning of the parsed files, once the parse
+// succeeds, so integrators can surface quality issues as they're found. The warnings are
+// in the Warnings of the files too.
+func WithWarningHandler(handler func(Warning)) Option {
+	return func(cfg *config) {
+		cfg.warningHandler = handler
+	}
+}
+
+// reportWarnings calls the warning handler of cfg (if any) with the warnings of file.
+func reportWarnings(file *File, cfg *config) {
+	if cfg.warningHandler == nil {
+		return
+	}
+	for _, warning := range file.Warnings {
+		cfg.warningHandler(*warning)
+	}
+}
+
+// iotaWarnings returns a warning for every constant of the const groups of file, parsed from
+// src, without value after a constant whose value doesn't use iota: the constant repeats
+// that value, which is rarely intended (and changes silently if the constants are
+// reordered). The warnings are located at the start of the names of the constants.
+func iotaWarnings(file *File, src []byte) []*Warning {
+	lines := lineStarts(src)
+	var warnings []*Warning
+	for _, node := range file.Children {
+		group, ok := node.(*Container)
+		if !ok || group.Type != ConstNode {
+			continue
+		}
+		start := group.HeaderSpan.Start
+		groupSrc := make([]byte, 0, len(chunkPrefix)+group.FooterSpan.End+1-start)
+		groupSrc = append(groupSrc, chunkPrefix...)
+		groupSrc = append(groupSrc, src[start:group.FooterSpan.End+1]...)
+		fset := token.NewFileSet()
+		fileAST, err := parser.ParseFile(fset, "", groupSrc, 0)
+		if err != nil || len(fileAST.Decls) != 1 {
+			continue
+		}
+		var values []ast.Expr
+		for _, spec := range fileAST.Decls[0].(*ast.GenDecl).Specs {
+			vs := spec.(*ast.ValueSpec)
+			if len(vs.Values) > 0 {
+				values = vs.Values
+				continue
+			}
+			if len(values) == 0 || usesIota(values) {
+				continue
+			}
+			offset := start + fset.Position(vs.Pos()).Offset - len(chunkPrefix)
+			warnings = append(warnings, &Warning{
+				Location: offsetLocation(lines, offset),
+				Message:  fmt.Sprintf("constant %s repeats a value without iota", vs.Names[0].Name),
+			})
+		}
+	}
+	return warnings
+}
+
+// usesIota reports whether any of exprs refers to iota.
+func usesIota(exprs []ast.Expr) bool {
+	found := false
+	for _, expr := range exprs {
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == "iota" {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
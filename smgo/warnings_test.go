@@ -0,0 +1,62 @@
+package smgo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIotaWarnings(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`package iota
+
+const (
+	A = iota
+	B
+)
+
+const (
+	C = 1 << iota
+	D
+	E = "e"
+	F
+	G, H = 1, 2
+	I, J
+)
+`)
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	assert.Equal(t, []*smgo.Warning{
+		{Location: smgo.Location{Line: 12, Column: 1}, Message: "constant F repeats a value without iota"},
+		{Location: smgo.Location{Line: 14, Column: 1}, Message: "constant I repeats a value without iota"},
+	}, file.Warnings)
+}
+
+func TestParseWithWarningHandler(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package handler\n\nfunc A() {\n}\n\nfunc A() {\n}\n")
+	var warnings []smgo.Warning
+	handler := smgo.WithWarningHandler(func(warning smgo.Warning) {
+		warnings = append(warnings, warning)
+	})
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8", handler)
+	require.Nil(t, err)
+	require.Len(t, file.Warnings, 1)
+	assert.Equal(t, []smgo.Warning{*file.Warnings[0]}, warnings)
+
+	// incremental parses report the warnings too
+	warnings = nil
+	src = []byte("package handler\n\nfunc A() {\n}\n\nfunc B() {\n}\n")
+	file, err = smgo.Parse(bytes.NewReader(src), "UTF-8", handler)
+	require.Nil(t, err)
+	assert.Empty(t, warnings)
+	_, err = file.ApplyEdits(src, []smgo.TextEdit{{len(src), len(src), "\nfunc B() {\n}\n"}}, handler)
+	require.Nil(t, err)
+	require.Len(t, file.Warnings, 1)
+	assert.Equal(t, []smgo.Warning{*file.Warnings[0]}, warnings)
+}
@@ -0,0 +1,224 @@
+package smgo
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Versions of the schema of the YAML written by Write, selected with WithSchema, so new fields
+// are only written for the SemanticMerge clients expecting them. Schema1 is the original one;
+// Schema2 adds the exported, deprecated and receiver fields of the declarations, and Schema3
+// writes the type aliases with their own type, AliasType.
+const (
+	Schema1      = 1
+	Schema2      = 2
+	Schema3      = 3
+	LatestSchema = Schema3
+)
+
+// AliasType is the type of the type aliases (e.g. "type A = B") from Schema3 on; before, they
+// are written as types.
+const AliasType = "Alias"
+
+// WriteOption configures how Write writes the declarations tree.
+type WriteOption func(*writeConfig)
+
+type writeConfig struct {
+	schema    int
+	typeNames map[string]string
+}
+
+// WithSchema writes the fields of the schema version (Schema1 by default).
+func WithSchema(version int) WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.schema = version
+	}
+}
+
+// WithTypeNames maps the types written by default (see TypeName) to the types written
+// instead, e.g. "Struct" to "class", to match the vocabulary of other SemanticMerge parsers.
+// AliasType maps the type aliases whatever the schema.
+func WithTypeNames(names map[string]string) WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.typeNames = names
+	}
+}
+
+// yamlFile, yamlContainer, yamlTerminal and yamlParsingError are the layout of the
+// declarations tree in the YAML format of SemanticMerge external parsers.
+type yamlFile struct {
+	Type                  string              `yaml:"type"`
+	Name                  string              `yaml:"name"`
+	LocationSpan          yamlLocationSpan    `yaml:"locationSpan,flow"`
+	FooterSpan            []int               `yaml:"footerSpan,flow"`
+	ParsingErrorsDetected bool                `yaml:"parsingErrorsDetected"`
+	Children              []interface{}       `yaml:"children,omitempty"`
+	ParsingErrors         []*yamlParsingError `yaml:"parsingErrors,omitempty"`
+}
+
+type yamlContainer struct {
+	Type         string           `yaml:"type"`
+	Name         string           `yaml:"name"`
+	ID           string           `yaml:"id,omitempty"`
+	Exported     bool             `yaml:"exported,omitempty"`
+	Deprecated   bool             `yaml:"deprecated,omitempty"`
+	LocationSpan yamlLocationSpan `yaml:"locationSpan,flow"`
+	HeaderSpan   []int            `yaml:"headerSpan,flow"`
+	FooterSpan   []int            `yaml:"footerSpan,flow"`
+	Children     []interface{}    `yaml:"children,omitempty"`
+}
+
+type yamlTerminal struct {
+	Type         string           `yaml:"type"`
+	Name         string           `yaml:"name"`
+	ID           string           `yaml:"id,omitempty"`
+	Exported     bool             `yaml:"exported,omitempty"`
+	Deprecated   bool             `yaml:"deprecated,omitempty"`
+	LocationSpan yamlLocationSpan `yaml:"locationSpan,flow"`
+	Span         []int            `yaml:"span,flow"`
+	Receiver     string           `yaml:"receiver,omitempty"`
+}
+
+// yamlLocationSpan is a struct, not a map, so the start is written before the end as in the
+// specification.
+type yamlLocationSpan struct {
+	Start []int `yaml:"start,flow"`
+	End   []int `yaml:"end,flow"`
+}
+
+type yamlParsingError struct {
+	Location []int  `yaml:"location,flow"`
+	Message  string `yaml:"message"`
+}
+
+// Write writes f, the declarations tree of the file in path name, in the YAML format of
+// SemanticMerge external parsers: the declarations have the type, name, location span and
+// spans of the specification (and their IDs, if set), and the file the parsing errors. The
+// fields added by later schema versions are written with WithSchema.
+func Write(w io.Writer, name string, f *File, opts ...WriteOption) error {
+	cfg := &writeConfig{schema: Schema1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	file := &yamlFile{
+		Type:                  "file",
+		Name:                  name,
+		LocationSpan:          toYAMLLocationSpan(f.LocationSpan),
+		FooterSpan:            []int{f.FooterSpan.Start, f.FooterSpan.End},
+		ParsingErrorsDetected: len(f.ParsingErrors) > 0,
+		Children:              cfg.nodes(f.Children),
+	}
+	for _, parsingError := range f.ParsingErrors {
+		file.ParsingErrors = append(file.ParsingErrors, &yamlParsingError{
+			Location: []int{parsingError.Location.Line, parsingError.Location.Column},
+			Message:  parsingError.Message,
+		})
+	}
+	encoder := yaml.NewEncoder(w)
+	err := encoder.Encode(file)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (cfg *writeConfig) nodes(nodes []Node) []interface{} {
+	children := make([]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *Terminal:
+			t := &yamlTerminal{
+				Type:         cfg.nodeType(n),
+				Name:         n.Name,
+				ID:           n.ID,
+				LocationSpan: toYAMLLocationSpan(n.LocationSpan),
+				Span:         []int{n.Span.Start, n.Span.End},
+			}
+			if cfg.schema >= Schema2 {
+				t.Exported, t.Deprecated, t.Receiver = n.Exported, n.Deprecated, n.Receiver
+			}
+			children = append(children, t)
+		case *Container:
+			c := &yamlContainer{
+				Type:         cfg.nodeType(n),
+				Name:         n.Name,
+				ID:           n.ID,
+				LocationSpan: toYAMLLocationSpan(n.LocationSpan),
+				HeaderSpan:   []int{n.HeaderSpan.Start, n.HeaderSpan.End},
+				FooterSpan:   []int{n.FooterSpan.Start, n.FooterSpan.End},
+				Children:     cfg.nodes(n.Children),
+			}
+			if cfg.schema >= Schema2 {
+				c.Exported, c.Deprecated = n.Exported, n.Deprecated
+			}
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// nodeType returns the type written for node, telling the type aliases apart from Schema3 on.
+func (cfg *writeConfig) nodeType(node Node) string {
+	return NodeTypeName(node, cfg.typeNames, cfg.schema >= Schema3)
+}
+
+// NodeTypeName returns the type written for node: its default type (see TypeName), or
+// AliasType for the type aliases if aliases is set, mapped with typeNames (see
+// WithTypeNames). AliasType maps the type aliases even if aliases isn't set.
+func NodeTypeName(node Node, typeNames map[string]string, aliases bool) string {
+	var name string
+	switch n := node.(type) {
+	case *Terminal:
+		name = TypeName(n.Type)
+		if n.Alias {
+			if mapped, ok := typeNames[AliasType]; ok {
+				return mapped
+			}
+			if aliases {
+				return AliasType
+			}
+		}
+	case *Container:
+		name = TypeName(n.Type)
+	}
+	if mapped, ok := typeNames[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+func toYAMLLocationSpan(ls LocationSpan) yamlLocationSpan {
+	return yamlLocationSpan{
+		Start: []int{ls.Start.Line, ls.Start.Column},
+		End:   []int{ls.End.Line, ls.End.Column},
+	}
+}
+
+// TypeName returns the type written by default for the nodes of type t: "Package",
+// "Function", "Field", "Import", "Constant", "Variable", "Type", "Struct" and "Interface";
+// other types (like comments) are "Unknown".
+func TypeName(t NodeType) string {
+	switch t {
+	case PackageNode:
+		return "Package"
+	case FunctionNode:
+		return "Function"
+	case FieldNode:
+		return "Field"
+	case ImportNode:
+		return "Import"
+	case ConstNode:
+		return "Constant"
+	case VarNode:
+		return "Variable"
+	case TypeNode:
+		return "Type"
+	case StructNode:
+		return "Struct"
+	case InterfaceNode:
+		return "Interface"
+	default:
+		return "Unknown"
+	}
+}
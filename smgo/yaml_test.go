@@ -0,0 +1,104 @@
+package smgo_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// TestWrite compares the output of Write with the golden files in testdata, written by hand
+// after the example of the external parser specification. The documents are compared
+// decoded, keeping the order of the keys.
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		src    string
+		golden string
+		opts   []smgo.WriteOption
+	}{
+		{"simple_func.go", "simple_func.yaml", nil},
+		{"simple_struct.go", "simple_struct.yaml", nil},
+		{"grouped_const.go", "grouped_const.yaml", nil},
+		{"comment_type.go", "comment_type.yaml", nil},
+		{"parsing_error.go_src", "parsing_error.yaml", nil},
+		{"simple_struct.go", "simple_struct_schema2.yaml", []smgo.WriteOption{
+			smgo.WithSchema(smgo.Schema2),
+			smgo.WithTypeNames(map[string]string{"Struct": "class", "Function": "method"}),
+		}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.golden, func(t *testing.T) {
+			t.Parallel()
+			path := "testdata/" + c.src
+			src, err := os.Open(path)
+			require.Nil(t, err)
+			defer src.Close()
+			file, err := smgo.Parse(src, "UTF-8")
+			require.Nil(t, err)
+
+			var buf bytes.Buffer
+			err = smgo.Write(&buf, path, file, c.opts...)
+			require.Nil(t, err)
+			var actual yaml.MapSlice
+			require.Nil(t, yaml.Unmarshal(buf.Bytes(), &actual))
+
+			golden, err := ioutil.ReadFile("testdata/" + c.golden)
+			require.Nil(t, err)
+			var expected yaml.MapSlice
+			require.Nil(t, yaml.Unmarshal(golden, &expected))
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestWriteLocationSpanOrder(t *testing.T) {
+	t.Parallel()
+
+	file, err := smgo.Parse(bytes.NewReader([]byte("package p\n")), "UTF-8")
+	require.Nil(t, err)
+	var buf bytes.Buffer
+	err = smgo.Write(&buf, "p.go", file)
+	require.Nil(t, err)
+	assert.Contains(t, buf.String(), "locationSpan: {start: [1, 0], end: [1, 10]}")
+}
+
+func TestWriteAliases(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package p\n\ntype A = B\n\ntype B int\n")
+	file, err := smgo.Parse(bytes.NewReader(src), "UTF-8")
+	require.Nil(t, err)
+	types := func(opts ...smgo.WriteOption) []string {
+		var buf bytes.Buffer
+		err := smgo.Write(&buf, "p.go", file, opts...)
+		require.Nil(t, err)
+		var f struct {
+			Children []struct {
+				Type string `yaml:"type"`
+			} `yaml:"children"`
+		}
+		require.Nil(t, yaml.Unmarshal(buf.Bytes(), &f))
+		var types []string
+		for _, child := range f.Children {
+			types = append(types, child.Type)
+		}
+		return types
+	}
+
+	assert.Equal(t, []string{"Package", "Type", "Type"}, types())
+	assert.Equal(t, []string{"Package", "Type", "Type"}, types(smgo.WithSchema(smgo.Schema2)))
+	assert.Equal(t, []string{"Package", "Alias", "Type"}, types(smgo.WithSchema(smgo.Schema3)))
+	// the aliases can be mapped in any schema, and the mapping of Type doesn't apply to them
+	names := smgo.WithTypeNames(map[string]string{"Alias": "alias", "Type": "type"})
+	assert.Equal(t, []string{"Package", "alias", "type"}, types(names))
+	names = smgo.WithTypeNames(map[string]string{"Type": "type"})
+	assert.Equal(t, []string{"Package", "Alias", "type"}, types(smgo.WithSchema(smgo.Schema3), names))
+}
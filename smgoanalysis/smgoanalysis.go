@@ -0,0 +1,72 @@
+// Package smgoanalysis exposes the smgo declarations tree as a golang.org/x/tools/go/analysis
+// analyzer, so other analyzers can require it instead of rebuilding the structure of the
+// files from their AST.
+package smgoanalysis
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer builds the declarations tree (with stable IDs) of every file of the package. Its
+// result is a Result.
+var Analyzer = &analysis.Analyzer{
+	Name:       "smgo",
+	Doc:        "build the SemanticMerge declarations tree of every file",
+	Run:        run,
+	ResultType: reflect.TypeOf(Result{}),
+}
+
+// Result maps every file of the analyzed package to its declarations tree.
+type Result map[*ast.File]*smgo.File
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	result := make(Result, len(pass.Files))
+	for _, f := range pass.Files {
+		tokenFile := pass.Fset.File(f.Pos())
+		if tokenFile == nil {
+			continue
+		}
+		src, err := ioutil.ReadFile(tokenFile.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %s", tokenFile.Name())
+		}
+		file, err := parse(src)
+		if err != nil {
+			if _, ok := err.(*panicError); ok {
+				// report the file, without stopping the analysis of the other packages
+				pass.Reportf(f.Pos(), "smgo: %v", err)
+				continue
+			}
+			return nil, errors.Wrapf(err, "error parsing %s", tokenFile.Name())
+		}
+		result[f] = file
+	}
+	return result, nil
+}
+
+// panicError is the error of a parse that panicked.
+type panicError struct {
+	value interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic parsing the file: %v", e.value)
+}
+
+// parse parses src, recovering from a panic of the parser as a *panicError.
+func parse(src []byte) (file *smgo.File, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			file, err = nil, &panicError{r}
+		}
+	}()
+	return smgo.Parse(bytes.NewReader(src), "UTF-8", smgo.WithStableIDs())
+}
@@ -0,0 +1,32 @@
+package smgoanalysis_test
+
+import (
+	"testing"
+
+	"github.com/jriquelme/SemanticMergeGO/smgo"
+	"github.com/jriquelme/SemanticMergeGO/smgoanalysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	results := analysistest.Run(t, analysistest.TestData(), smgoanalysis.Analyzer, "a")
+	require.Len(t, results, 1)
+	result, ok := results[0].Result.(smgoanalysis.Result)
+	require.True(t, ok)
+	require.Len(t, result, 1)
+	for f, file := range result {
+		assert.Equal(t, "a", f.Name.Name)
+		require.Len(t, file.Children, 4)
+		assert.Equal(t, smgo.PackageNode, file.Children[0].(*smgo.Terminal).Type)
+		person := file.Children[2].(*smgo.Container)
+		assert.Equal(t, "Person", person.Name)
+		assert.NotEmpty(t, person.ID)
+		require.Len(t, person.Children, 2)
+		assert.Equal(t, "*Named", person.Children[0].(*smgo.Terminal).Name)
+		assert.Equal(t, "SayHi", file.Children[3].(*smgo.Terminal).Name)
+	}
+}
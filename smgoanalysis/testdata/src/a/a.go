@@ -0,0 +1,14 @@
+package a
+
+type Named struct {
+	Name string
+}
+
+type Person struct {
+	*Named
+	Age int
+}
+
+func (p *Person) SayHi() {
+	println("Hi, I'm " + p.Name)
+}